@@ -0,0 +1,113 @@
+package api
+
+import (
+	"slices"
+
+	"github.com/revett/miniflux-sync/diff"
+)
+
+// optionCapability is the version of Miniflux which added a feed option. Older servers ignore the
+// option, so it would never match the data file.
+type optionCapability struct {
+	option string
+	since  ServerVersion
+	// remove removes the option from the options, returning true if it was set.
+	remove func(o *diff.FeedOptions) bool
+}
+
+// optionCapabilities are the feed options which are not supported by every version of Miniflux 2,
+// from the Miniflux changelog. Other options have been supported since 2.0.0.
+var optionCapabilities = []optionCapability{
+	{"user_agent", ServerVersion{2, 0, 12}, func(o *diff.FeedOptions) bool {
+		set := o.UserAgent != nil
+		o.UserAgent = nil
+		return set
+	}},
+	{"disabled", ServerVersion{2, 0, 17}, func(o *diff.FeedOptions) bool {
+		set := o.Disabled != nil
+		o.Disabled = nil
+		return set
+	}},
+	{"ignore_http_cache", ServerVersion{2, 0, 22}, func(o *diff.FeedOptions) bool {
+		set := o.IgnoreHTTPCache != nil
+		o.IgnoreHTTPCache = nil
+		return set
+	}},
+	{"fetch_via_proxy", ServerVersion{2, 0, 24}, func(o *diff.FeedOptions) bool {
+		set := o.FetchViaProxy != nil
+		o.FetchViaProxy = nil
+		return set
+	}},
+	{"blocklist_rules", ServerVersion{2, 0, 25}, func(o *diff.FeedOptions) bool {
+		set := o.BlocklistRules != nil
+		o.BlocklistRules = nil
+		return set
+	}},
+	{"keeplist_rules", ServerVersion{2, 0, 25}, func(o *diff.FeedOptions) bool {
+		set := o.KeeplistRules != nil
+		o.KeeplistRules = nil
+		return set
+	}},
+	{"allow_self_signed_certificates", ServerVersion{2, 0, 29}, func(o *diff.FeedOptions) bool {
+		set := o.AllowSelfSignedCertificates != nil
+		o.AllowSelfSignedCertificates = nil
+		return set
+	}},
+	{"cookie", ServerVersion{2, 0, 30}, func(o *diff.FeedOptions) bool {
+		set := o.Cookie != nil
+		o.Cookie = nil
+		return set
+	}},
+	{"hide_globally", ServerVersion{2, 0, 33}, func(o *diff.FeedOptions) bool {
+		set := o.HideGlobally != nil
+		o.HideGlobally = nil
+		return set
+	}},
+	{"disable_http2", ServerVersion{2, 1, 1}, func(o *diff.FeedOptions) bool {
+		set := o.DisableHTTP2 != nil
+		o.DisableHTTP2 = nil
+		return set
+	}},
+}
+
+// UnsupportedOption is a feed option which is set in the data file, but not supported by the
+// server.
+type UnsupportedOption struct {
+	FeedURL string
+	Option  string
+	Since   ServerVersion
+}
+
+// RemoveUnsupportedOptions removes the feed options which the server version does not support from
+// the state, returning the options removed in the order of the categories and feeds. Nothing is
+// removed when the version is unknown.
+func RemoveUnsupportedOptions(state *diff.State, version ServerVersion) []UnsupportedOption {
+	unsupported := []UnsupportedOption{}
+
+	if !version.Known() {
+		return unsupported
+	}
+
+	categoryTitles := state.CategoryTitles()
+	slices.Sort(categoryTitles)
+
+	for _, categoryTitle := range categoryTitles {
+		feeds := state.FeedsByCategoryTitle[categoryTitle]
+
+		for i := range feeds {
+			for _, capability := range optionCapabilities {
+				if version.AtLeast(capability.since) || !capability.remove(&feeds[i].Options) {
+					continue
+				}
+
+				unsupported = append(unsupported, UnsupportedOption{
+					FeedURL: feeds[i].URL,
+					Option:  capability.option,
+					Since:   capability.since,
+				})
+			}
+		}
+	}
+
+	return unsupported
+}
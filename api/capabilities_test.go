@@ -0,0 +1,88 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/revett/miniflux-sync/diff"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRemoveUnsupportedOptions(t *testing.T) {
+	t.Parallel()
+
+	enabled := true
+	cookie := "session=1"
+	rules := "article"
+
+	newState := func() *diff.State {
+		return &diff.State{
+			FeedURLsByCategoryTitle: map[string][]string{
+				"news": {"https://example.com/news.xml"},
+				"tech": {"https://example.com/tech.xml"},
+			},
+			FeedsByCategoryTitle: map[string][]diff.Feed{
+				"news": {{
+					URL: "https://example.com/news.xml",
+					Options: diff.FeedOptions{
+						Cookie:       &cookie,
+						DisableHTTP2: &enabled,
+						ScraperRules: &rules,
+					},
+				}},
+				"tech": {{
+					URL:     "https://example.com/tech.xml",
+					Options: diff.FeedOptions{HideGlobally: &enabled},
+				}},
+			},
+		}
+	}
+
+	tests := map[string]struct {
+		version     ServerVersion
+		unsupported []UnsupportedOption
+		newsOptions diff.FeedOptions
+	}{
+		"Latest": {
+			version:     ServerVersion{2, 2, 0},
+			unsupported: []UnsupportedOption{},
+			newsOptions: diff.FeedOptions{
+				Cookie: &cookie, DisableHTTP2: &enabled, ScraperRules: &rules,
+			},
+		},
+		"Unknown": {
+			unsupported: []UnsupportedOption{},
+			newsOptions: diff.FeedOptions{
+				Cookie: &cookie, DisableHTTP2: &enabled, ScraperRules: &rules,
+			},
+		},
+		"WithoutDisableHTTP2": {
+			version: ServerVersion{2, 1, 0},
+			unsupported: []UnsupportedOption{
+				{FeedURL: "https://example.com/news.xml", Option: "disable_http2", Since: ServerVersion{2, 1, 1}},
+			},
+			newsOptions: diff.FeedOptions{Cookie: &cookie, ScraperRules: &rules},
+		},
+		"Old": {
+			version: ServerVersion{2, 0, 29},
+			unsupported: []UnsupportedOption{
+				{FeedURL: "https://example.com/news.xml", Option: "cookie", Since: ServerVersion{2, 0, 30}},
+				{FeedURL: "https://example.com/news.xml", Option: "disable_http2", Since: ServerVersion{2, 1, 1}},
+				{FeedURL: "https://example.com/tech.xml", Option: "hide_globally", Since: ServerVersion{2, 0, 33}},
+			},
+			newsOptions: diff.FeedOptions{ScraperRules: &rules},
+		},
+	}
+
+	for name, testCase := range tests {
+		tc := testCase
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			state := newState()
+
+			require.Equal(t, tc.unsupported, RemoveUnsupportedOptions(state, tc.version))
+			require.Equal(t, tc.newsOptions, state.FeedsByCategoryTitle["news"][0].Options)
+		})
+	}
+}
@@ -5,19 +5,76 @@ import (
 
 	"github.com/pkg/errors"
 	"github.com/revett/miniflux-sync/config"
+	"github.com/revett/miniflux-sync/diff"
 	"github.com/revett/miniflux-sync/log"
 	miniflux "miniflux.app/v2/client"
 )
 
+// MinifluxClient is the subset of the Miniflux API used by miniflux-sync, so that it can be
+// replaced in tests. Each request is sent with the context of the call.
+type MinifluxClient interface {
+	Healthcheck(ctx context.Context) error
+	Me(ctx context.Context) (*miniflux.User, error)
+	Version(ctx context.Context) (*miniflux.VersionResponse, error)
+	Categories(ctx context.Context) (miniflux.Categories, error)
+	CreateCategory(ctx context.Context, title string) (*miniflux.Category, error)
+	DeleteCategory(ctx context.Context, categoryID int64) error
+	Feeds(ctx context.Context) (miniflux.Feeds, error)
+	Feed(ctx context.Context, feedID int64) (*miniflux.Feed, error)
+	CreateFeed(ctx context.Context, req *miniflux.FeedCreationRequest) (int64, error)
+	UpdateFeed(
+		ctx context.Context, feedID int64, req *miniflux.FeedModificationRequest,
+	) (*miniflux.Feed, error)
+	DeleteFeed(ctx context.Context, feedID int64) error
+}
+
 // Client creates a new Miniflux API client, whilst checking the health of the Miniflux instance.
-func Client(ctx context.Context, cfg *config.GlobalFlags) (*miniflux.Client, error) {
+// Each client has its own HTTP transport, configured from cfg. The current user is logged, and
+// when an identity is expected, an error is returned unless the user and endpoint match it. The
+// version of the server is detected, for DetectedVersion.
+func Client(
+	ctx context.Context, cfg *config.GlobalFlags, expected *diff.Identity,
+) (MinifluxClient, error) {
 	log.Info(ctx, "connecting to miniflux instance")
-	client := miniflux.New(cfg.Endpoint, cfg.APIKey)
+
+	transport, err := newTransport(ctx, cfg)
+	if err != nil {
+		return nil, errors.Wrap(err, "configuring http transport")
+	}
+
+	endpoint := clientEndpoint(cfg.Endpoint)
+
+	client := newHTTPClient(endpoint, transport, cfg.APIKey, "", "")
+	if cfg.UsesBasicAuth() {
+		log.Info(ctx, "authenticating with username and password", log.Metadata{
+			"username": cfg.Username,
+		})
+
+		client = newHTTPClient(endpoint, transport, "", cfg.Username, cfg.Password)
+	}
+
+	retry := NewRetryPolicy(cfg)
 
 	log.Info(ctx, "checking health of miniflux instance")
-	if err := client.Healthcheck(); err != nil {
+	if err := withRetryNoResult(
+		ctx, retry, "checking health of miniflux instance", func() error {
+			return client.Healthcheck(ctx)
+		},
+	); err != nil {
 		return nil, errors.Wrap(err, "checking health of miniflux instance")
 	}
 
-	return client, nil
+	// Usernames are redacted from recorded sessions, so only the endpoint is checked on replay.
+	if cfg.Replay != "" && expected != nil {
+		expected = &diff.Identity{Endpoint: expected.Endpoint}
+	}
+
+	if err := checkIdentity(ctx, client, cfg.Endpoint, expected, retry); err != nil {
+		return nil, errors.Wrap(err, "checking identity")
+	}
+
+	return &versionedClient{
+		MinifluxClient: client,
+		version:        detectVersion(ctx, client, retry),
+	}, nil
 }
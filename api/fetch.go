@@ -10,18 +10,24 @@ import (
 
 // FetchData fetches feeds and categories from the Miniflux instance.
 func FetchData(
-	ctx context.Context, client *miniflux.Client,
+	ctx context.Context, client MinifluxClient, retry RetryPolicy,
 ) ([]*miniflux.Feed, []*miniflux.Category, error) {
 	log.Info(ctx, "fetching feeds")
 
-	feeds, err := client.Feeds()
+	feeds, err := withRetry(ctx, retry, "fetching feeds", func() (miniflux.Feeds, error) {
+		return client.Feeds(ctx)
+	})
 	if err != nil {
 		return nil, nil, errors.Wrap(err, "fetching feeds")
 	}
 
 	log.Info(ctx, "fetching categories")
 
-	categories, err := client.Categories()
+	categories, err := withRetry(
+		ctx, retry, "fetching categories", func() (miniflux.Categories, error) {
+			return client.Categories(ctx)
+		},
+	)
 	if err != nil {
 		return nil, nil, errors.Wrap(err, "fetching categories")
 	}
@@ -0,0 +1,257 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/pkg/errors"
+	miniflux "miniflux.app/v2/client"
+)
+
+const (
+	// userAgent is sent with every Miniflux API request.
+	userAgent = "miniflux-sync"
+
+	// defaultRequestTimeout limits each request, matching the Miniflux client library.
+	defaultRequestTimeout = 80 * time.Second
+)
+
+// httpClient is a Miniflux API client which sends every request with the context of the call,
+// using its own HTTP client. The Miniflux client library is not used for requests, as it only
+// sends them through http.DefaultTransport, and without a context. Errors match those of the
+// library, so miniflux.ErrNotFound and the other library errors can be checked for.
+type httpClient struct {
+	endpoint string
+	apiKey   string
+	username string
+	password string
+	client   *http.Client
+}
+
+// newHTTPClient creates an httpClient for endpoint which sends requests using transport. As with the
+// Miniflux client library, the endpoint can end with "/v1". It authenticates with the API key,
+// unless a username and password are given.
+func newHTTPClient(
+	endpoint string, transport http.RoundTripper, apiKey string, username string, password string,
+) *httpClient {
+	return &httpClient{
+		endpoint: baseURL(endpoint),
+		apiKey:   apiKey,
+		username: username,
+		password: password,
+		client: &http.Client{
+			Transport: transport,
+			Timeout:   defaultRequestTimeout,
+		},
+	}
+}
+
+// Healthcheck checks that the Miniflux instance is healthy.
+func (c *httpClient) Healthcheck(ctx context.Context) error {
+	resp, err := c.send(ctx, http.MethodGet, "/healthcheck", nil)
+	if err != nil {
+		return errors.Wrap(err, "miniflux: unable to perform healthcheck")
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return errors.Wrap(err, "miniflux: unable to read healthcheck response")
+	}
+
+	if string(body) != "OK" {
+		return errors.Errorf("miniflux: invalid healthcheck response: %q", body)
+	}
+
+	return nil
+}
+
+// Me returns the authenticated user.
+func (c *httpClient) Me(ctx context.Context) (*miniflux.User, error) {
+	var user *miniflux.User
+	return user, c.do(ctx, http.MethodGet, "/v1/me", nil, &user)
+}
+
+// Version returns the version of the Miniflux instance.
+func (c *httpClient) Version(ctx context.Context) (*miniflux.VersionResponse, error) {
+	var version *miniflux.VersionResponse
+	return version, c.do(ctx, http.MethodGet, "/v1/version", nil, &version)
+}
+
+// LegacyVersion returns the version of a Miniflux instance older than 2.0.49, from the "/version"
+// endpoint, which returns it as plain text.
+func (c *httpClient) LegacyVersion(ctx context.Context) (string, error) {
+	resp, err := c.send(ctx, http.MethodGet, "/version", nil)
+	if err != nil {
+		return "", errors.Wrap(err, "fetching version")
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 64)) //nolint:mnd
+	if err != nil {
+		return "", errors.Wrap(err, "reading version")
+	}
+
+	return string(body), nil
+}
+
+// Categories returns every category.
+func (c *httpClient) Categories(ctx context.Context) (miniflux.Categories, error) {
+	var categories miniflux.Categories
+	return categories, c.do(ctx, http.MethodGet, "/v1/categories", nil, &categories)
+}
+
+// CreateCategory creates a category.
+func (c *httpClient) CreateCategory(ctx context.Context, title string) (*miniflux.Category, error) {
+	var category *miniflux.Category
+	return category, c.do(
+		ctx, http.MethodPost, "/v1/categories", map[string]string{"title": title}, &category,
+	)
+}
+
+// DeleteCategory deletes a category, along with its feeds.
+func (c *httpClient) DeleteCategory(ctx context.Context, categoryID int64) error {
+	return c.do(ctx, http.MethodDelete, fmt.Sprintf("/v1/categories/%d", categoryID), nil, nil)
+}
+
+// Feeds returns every feed.
+func (c *httpClient) Feeds(ctx context.Context) (miniflux.Feeds, error) {
+	var feeds miniflux.Feeds
+	return feeds, c.do(ctx, http.MethodGet, "/v1/feeds", nil, &feeds)
+}
+
+// Feed returns a single feed.
+func (c *httpClient) Feed(ctx context.Context, feedID int64) (*miniflux.Feed, error) {
+	var feed *miniflux.Feed
+	return feed, c.do(ctx, http.MethodGet, fmt.Sprintf("/v1/feeds/%d", feedID), nil, &feed)
+}
+
+// CreateFeed creates a feed, returning its ID.
+func (c *httpClient) CreateFeed(
+	ctx context.Context, req *miniflux.FeedCreationRequest,
+) (int64, error) {
+	var result struct {
+		FeedID int64 `json:"feed_id"`
+	}
+
+	return result.FeedID, c.do(ctx, http.MethodPost, "/v1/feeds", req, &result)
+}
+
+// UpdateFeed updates the options of a feed.
+func (c *httpClient) UpdateFeed(
+	ctx context.Context, feedID int64, req *miniflux.FeedModificationRequest,
+) (*miniflux.Feed, error) {
+	var feed *miniflux.Feed
+	return feed, c.do(ctx, http.MethodPut, fmt.Sprintf("/v1/feeds/%d", feedID), req, &feed)
+}
+
+// DeleteFeed deletes a feed.
+func (c *httpClient) DeleteFeed(ctx context.Context, feedID int64) error {
+	return c.do(ctx, http.MethodDelete, fmt.Sprintf("/v1/feeds/%d", feedID), nil, nil)
+}
+
+// do sends a request with body encoded as JSON, and decodes the response into result, unless
+// result is nil.
+func (c *httpClient) do(
+	ctx context.Context, method string, path string, body any, result any,
+) error {
+	resp, err := c.send(ctx, method, path, body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if result == nil || resp.StatusCode == http.StatusNoContent {
+		return nil
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(result); err != nil {
+		return errors.Wrap(err, "miniflux: response error")
+	}
+
+	return nil
+}
+
+// send sends an authenticated request to the API, returning an error for unsuccessful responses.
+// The caller must close the body of the response.
+func (c *httpClient) send(
+	ctx context.Context, method string, path string, body any,
+) (*http.Response, error) {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, errors.Wrap(err, "encoding request body")
+		}
+
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.endpoint+path, reader)
+	if err != nil {
+		return nil, errors.Wrap(err, "creating request")
+	}
+
+	req.Header.Set("User-Agent", userAgent)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+
+	if c.username != "" && c.password != "" {
+		req.SetBasicAuth(c.username, c.password)
+	} else {
+		req.Header.Set("X-Auth-Token", c.apiKey)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err //nolint:wrapcheck
+	}
+
+	if err := responseError(resp); err != nil {
+		resp.Body.Close()
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+// responseError returns the error for an unsuccessful response, with the same message as the
+// Miniflux client library.
+func responseError(resp *http.Response) error {
+	switch resp.StatusCode {
+	case http.StatusUnauthorized:
+		return miniflux.ErrNotAuthorized
+	case http.StatusForbidden:
+		return miniflux.ErrForbidden
+	case http.StatusNotFound:
+		return miniflux.ErrNotFound
+	case http.StatusBadRequest:
+		return fmt.Errorf("%w (%s)", miniflux.ErrBadRequest, errorMessage(resp))
+	case http.StatusInternalServerError:
+		return fmt.Errorf("%w: %s", miniflux.ErrServerError, errorMessage(resp))
+	}
+
+	if resp.StatusCode > http.StatusBadRequest {
+		return errors.Errorf("miniflux: status code=%d", resp.StatusCode)
+	}
+
+	return nil
+}
+
+// errorMessage returns the message from the JSON body of an error response.
+func errorMessage(resp *http.Response) string {
+	var body struct {
+		ErrorMessage string `json:"error_message"`
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return "unreadable error response"
+	}
+
+	return body.ErrorMessage
+}
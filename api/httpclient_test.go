@@ -0,0 +1,148 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/revett/miniflux-sync/minifluxtest"
+	"github.com/stretchr/testify/require"
+	miniflux "miniflux.app/v2/client"
+)
+
+func TestHTTPClient_Authentication(t *testing.T) {
+	t.Parallel()
+
+	server := minifluxtest.NewServer()
+	t.Cleanup(server.Close)
+
+	tests := map[string]struct {
+		client *httpClient
+		err    error
+	}{
+		"APIKey": {
+			client: newHTTPClient(server.URL, http.DefaultTransport, minifluxtest.APIKey, "", ""),
+		},
+		"EndpointWithVersion": {
+			client: newHTTPClient(server.URL+"/v1/", http.DefaultTransport, minifluxtest.APIKey, "", ""),
+		},
+		"BasicAuth": {
+			client: newHTTPClient(
+				server.URL, http.DefaultTransport, "", minifluxtest.Username, minifluxtest.Password,
+			),
+		},
+		"WrongAPIKey": {
+			client: newHTTPClient(server.URL, http.DefaultTransport, "wrong", "", ""),
+			err:    miniflux.ErrNotAuthorized,
+		},
+	}
+
+	for name, testCase := range tests {
+		tc := testCase
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			user, err := tc.client.Me(context.Background())
+			if tc.err != nil {
+				require.ErrorIs(t, err, tc.err)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, minifluxtest.Username, user.Username)
+		})
+	}
+}
+
+func TestHTTPClient_Errors(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		fault minifluxtest.Fault
+		err   error
+		msg   string
+	}{
+		"NotFound": {
+			fault: minifluxtest.Fault{StatusCode: http.StatusNotFound},
+			err:   miniflux.ErrNotFound,
+		},
+		"BadRequest": {
+			fault: minifluxtest.Fault{StatusCode: http.StatusBadRequest, Message: "invalid feed"},
+			err:   miniflux.ErrBadRequest,
+			msg:   "miniflux: bad request (invalid feed)",
+		},
+		"ServerError": {
+			fault: minifluxtest.Fault{StatusCode: http.StatusInternalServerError, Message: "database"},
+			err:   miniflux.ErrServerError,
+			msg:   "miniflux: internal server error: database",
+		},
+		"ServiceUnavailable": {
+			fault: minifluxtest.Fault{StatusCode: http.StatusServiceUnavailable},
+			msg:   "miniflux: status code=503",
+		},
+	}
+
+	for name, testCase := range tests {
+		tc := testCase
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			server := minifluxtest.NewServer()
+			t.Cleanup(server.Close)
+
+			tc.fault.Path = "/v1/feeds"
+			server.InjectFault(tc.fault)
+
+			client := newHTTPClient(server.URL, http.DefaultTransport, minifluxtest.APIKey, "", "")
+
+			_, err := client.Feeds(context.Background())
+			if tc.err != nil {
+				require.ErrorIs(t, err, tc.err)
+			}
+
+			require.ErrorContains(t, err, tc.msg)
+		})
+	}
+}
+
+func TestHTTPClient_Context(t *testing.T) {
+	t.Parallel()
+
+	server := minifluxtest.NewServer()
+	t.Cleanup(server.Close)
+	server.SetLatency(time.Second)
+
+	client := newHTTPClient(server.URL, http.DefaultTransport, minifluxtest.APIKey, "", "")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := client.Feeds(ctx)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	require.Less(t, time.Since(start), time.Second)
+}
+
+func TestHTTPClient_LegacyVersion(t *testing.T) {
+	t.Parallel()
+
+	// The request is answered by the transport of the client, as the endpoint cannot be reached.
+	path := filepath.Join(t.TempDir(), "session.jsonl")
+	require.NoError(t, os.WriteFile(path, []byte(
+		`{"request":{"method":"GET","path":"/version"},"response":{"status_code":200,"body":"2.0.48"}}`+"\n",
+	), 0o600))
+
+	replay, err := newReplayTransport(path)
+	require.NoError(t, err)
+
+	client := newHTTPClient("http://miniflux.invalid", replay, minifluxtest.APIKey, "", "")
+
+	version, err := client.LegacyVersion(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, "2.0.48", version)
+}
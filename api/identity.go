@@ -0,0 +1,82 @@
+package api
+
+import (
+	"context"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/revett/miniflux-sync/diff"
+	"github.com/revett/miniflux-sync/log"
+	miniflux "miniflux.app/v2/client"
+)
+
+// ErrIdentityMismatch is returned when the Miniflux account does not match the identity declared
+// in the data file.
+var ErrIdentityMismatch = errors.New("connected to a different miniflux account than the data file declares")
+
+// checkIdentity fetches the current user, logging it, and checks it against the expected identity,
+// if any. The user is only required when there is an identity to check.
+func checkIdentity(
+	ctx context.Context,
+	client MinifluxClient,
+	endpoint string,
+	expected *diff.Identity,
+	retry RetryPolicy,
+) error {
+	user, err := withRetry(ctx, retry, "fetching current user", func() (*miniflux.User, error) {
+		return client.Me(ctx)
+	})
+	if err != nil {
+		if expected == nil {
+			log.Warn(ctx, "could not fetch current miniflux user", log.Metadata{
+				"error": err.Error(),
+			})
+
+			return nil
+		}
+
+		return errors.Wrap(err, "fetching current user")
+	}
+
+	log.Info(ctx, "connected to miniflux as user", log.Metadata{
+		"username": user.Username,
+		"admin":    user.IsAdmin,
+	})
+
+	if expected == nil {
+		return nil
+	}
+
+	return matchIdentity(user, endpoint, expected)
+}
+
+// matchIdentity checks the user and endpoint against the expected identity.
+func matchIdentity(user *miniflux.User, endpoint string, expected *diff.Identity) error {
+	if expected.Username != "" && expected.Username != user.Username {
+		return errors.Wrapf(
+			ErrIdentityMismatch, `expected user "%s", but authenticated as "%s"`,
+			expected.Username, user.Username,
+		)
+	}
+
+	if expected.Endpoint != "" && normalizeEndpoint(expected.Endpoint) != normalizeEndpoint(endpoint) {
+		return errors.Wrapf(
+			ErrIdentityMismatch, `expected endpoint "%s", but connected to "%s"`,
+			expected.Endpoint, endpoint,
+		)
+	}
+
+	return nil
+}
+
+// normalizeEndpoint removes the parts of an endpoint which the Miniflux client ignores, so
+// equivalent endpoints compare as equal.
+func normalizeEndpoint(endpoint string) string {
+	return strings.ToLower(baseURL(endpoint))
+}
+
+// baseURL returns the endpoint without the trailing "/" or "/v1", as the Miniflux client does.
+func baseURL(endpoint string) string {
+	endpoint = strings.TrimSuffix(endpoint, "/")
+	return strings.TrimSuffix(endpoint, "/v1")
+}
@@ -0,0 +1,113 @@
+package api
+
+import (
+	"context"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/revett/miniflux-sync/diff"
+	"github.com/revett/miniflux-sync/log"
+	"github.com/stretchr/testify/require"
+	miniflux "miniflux.app/v2/client"
+)
+
+func TestMatchIdentity(t *testing.T) {
+	t.Parallel()
+
+	user := &miniflux.User{ID: 1, Username: "alice"}
+	endpoint := "https://miniflux.example.com/"
+
+	tests := map[string]struct {
+		expected diff.Identity
+		err      string
+	}{
+		"Username": {
+			expected: diff.Identity{Username: "alice"},
+		},
+		"Endpoint": {
+			expected: diff.Identity{Endpoint: "https://MINIFLUX.example.com/v1"},
+		},
+		"UsernameAndEndpoint": {
+			expected: diff.Identity{Username: "alice", Endpoint: "https://miniflux.example.com"},
+		},
+		"WrongUsername": {
+			expected: diff.Identity{Username: "bob"},
+			err:      `expected user "bob", but authenticated as "alice"`,
+		},
+		"WrongEndpoint": {
+			expected: diff.Identity{Username: "alice", Endpoint: "https://other.example.com"},
+			err:      `expected endpoint "https://other.example.com"`,
+		},
+	}
+
+	for name, testCase := range tests {
+		tc := testCase
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			err := matchIdentity(user, endpoint, &tc.expected)
+			if tc.err != "" {
+				require.ErrorIs(t, err, ErrIdentityMismatch)
+				require.ErrorContains(t, err, tc.err)
+
+				return
+			}
+
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestCheckIdentity(t *testing.T) {
+	t.Parallel()
+
+	errMe := errors.New("not found")
+
+	tests := map[string]struct {
+		expected *diff.Identity
+		meErr    error
+		err      error
+	}{
+		"NoIdentity": {},
+		"Match": {
+			expected: &diff.Identity{Username: "fake"},
+		},
+		"Mismatch": {
+			expected: &diff.Identity{Username: "alice"},
+			err:      ErrIdentityMismatch,
+		},
+		"UserUnavailableWithoutIdentity": {
+			meErr: errMe,
+		},
+		"UserUnavailableWithIdentity": {
+			expected: &diff.Identity{Username: "fake"},
+			meErr:    errMe,
+			err:      errMe,
+		},
+	}
+
+	for name, testCase := range tests {
+		tc := testCase
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			logger := log.New()
+			ctx := logger.WithContext(context.Background())
+
+			client := newFakeClient(nil, nil)
+			if tc.meErr != nil {
+				client.errs["Me"] = tc.meErr
+			}
+
+			err := checkIdentity(ctx, client, "https://miniflux.example.com", tc.expected, RetryPolicy{})
+			if tc.err != nil {
+				require.ErrorIs(t, err, tc.err)
+				return
+			}
+
+			require.NoError(t, err)
+		})
+	}
+}
@@ -0,0 +1,220 @@
+package api
+
+import (
+	"context"
+	"io"
+	"math/rand/v2"
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/revett/miniflux-sync/config"
+	"github.com/revett/miniflux-sync/log"
+	miniflux "miniflux.app/v2/client"
+)
+
+// RetryPolicy configures how transient Miniflux API failures are retried.
+type RetryPolicy struct {
+	// MaxRetries is the number of retries after the first attempt. Zero disables retries.
+	MaxRetries int
+	// InitialBackoff is the delay before the first retry, which doubles after each attempt.
+	InitialBackoff time.Duration
+	// MaxBackoff caps the delay between retries.
+	MaxBackoff time.Duration
+}
+
+// NewRetryPolicy creates a RetryPolicy from the global flags.
+func NewRetryPolicy(cfg *config.GlobalFlags) RetryPolicy {
+	return RetryPolicy{
+		MaxRetries:     cfg.Retries,
+		InitialBackoff: cfg.RetryBackoff,
+		MaxBackoff:     cfg.RetryMaxBackoff,
+	}
+}
+
+// statusCodePattern matches the error returned by the Miniflux client for unexpected status codes.
+var statusCodePattern = regexp.MustCompile(`miniflux: status code=(\d+)`)
+
+// withRetry calls fn, retrying with exponential backoff whilst it returns a transient error. It
+// must only be used for idempotent calls. No attempt is made once ctx is done.
+func withRetry[T any](
+	ctx context.Context, policy RetryPolicy, operation string, fn func() (T, error),
+) (T, error) {
+	var (
+		result T
+		err    error
+	)
+
+	for attempt := 0; ; attempt++ {
+		if err := ctx.Err(); err != nil {
+			return result, errors.WithStack(err)
+		}
+
+		result, err = fn()
+		if err == nil || !isTransient(err) || attempt >= policy.MaxRetries {
+			return result, err
+		}
+
+		delay := policy.backoff(attempt)
+
+		log.Warn(ctx, "retrying after transient failure", log.Metadata{
+			"operation": operation,
+			"attempt":   attempt + 1,
+			"delay":     delay.String(),
+			"error":     err.Error(),
+		})
+
+		select {
+		case <-ctx.Done():
+			return result, errors.Wrap(ctx.Err(), "waiting to retry")
+		case <-time.After(delay):
+		}
+	}
+}
+
+// withRetryNoResult is withRetry for calls which only return an error.
+func withRetryNoResult(
+	ctx context.Context, policy RetryPolicy, operation string, fn func() error,
+) error {
+	_, err := withRetry(ctx, policy, operation, func() (struct{}, error) {
+		return struct{}{}, fn()
+	})
+
+	return err
+}
+
+// withRetryDelete is withRetry for deletes. If an earlier attempt failed transiently, the delete
+// may have succeeded without a response, so a retry that finds nothing to delete is a success.
+func withRetryDelete(
+	ctx context.Context, policy RetryPolicy, operation string, fn func() error,
+) error {
+	attempts := 0
+
+	return withRetryNoResult(ctx, policy, operation, func() error {
+		attempts++
+
+		err := fn()
+		if attempts > 1 && errors.Is(err, miniflux.ErrNotFound) {
+			return nil
+		}
+
+		return err
+	})
+}
+
+// backoff returns the delay before the retry following attempt, with jitter so that concurrent
+// workers do not retry in lockstep.
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	delay := p.InitialBackoff
+	for range attempt {
+		delay *= 2
+		if p.MaxBackoff > 0 && delay >= p.MaxBackoff {
+			delay = p.MaxBackoff
+			break
+		}
+	}
+
+	if delay <= 0 {
+		return 0
+	}
+
+	half := delay / 2
+	return half + rand.N(half+1) //nolint:gosec
+}
+
+// isTransient returns true if err is likely to succeed if retried: timeouts, dropped connections,
+// rate limiting and server errors.
+func isTransient(err error) bool {
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+
+	if errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, syscall.EPIPE) ||
+		errors.Is(err, io.EOF) ||
+		errors.Is(err, io.ErrUnexpectedEOF) {
+		return true
+	}
+
+	// The Miniflux client does not expose status codes, so they are recovered from the error.
+	if errors.Is(err, miniflux.ErrServerError) ||
+		strings.Contains(err.Error(), "miniflux: internal server error") {
+		return true
+	}
+
+	matches := statusCodePattern.FindStringSubmatch(err.Error())
+	if len(matches) != 2 { //nolint:mnd
+		return false
+	}
+
+	statusCode, convErr := strconv.Atoi(matches[1])
+	if convErr != nil {
+		return false
+	}
+
+	return statusCode == 429 || statusCode >= 500 //nolint:mnd
+}
+
+// createWithRetry calls create, retrying whilst it returns a transient error. Creates are not
+// idempotent, so after each transient failure find is used to check whether the create succeeded
+// without a response, and its result is used instead of trying again.
+func createWithRetry[T any](
+	ctx context.Context,
+	policy RetryPolicy,
+	operation string,
+	create func() (T, error),
+	find func() (T, bool, error),
+) (T, error) {
+	for attempt := 0; ; attempt++ {
+		if err := ctx.Err(); err != nil {
+			var zero T
+			return zero, errors.WithStack(err)
+		}
+
+		result, err := create()
+		if err == nil || !isTransient(err) || attempt >= policy.MaxRetries {
+			return result, err
+		}
+
+		delay := policy.backoff(attempt)
+
+		log.Warn(ctx, "checking whether create succeeded after transient failure", log.Metadata{
+			"operation": operation,
+			"attempt":   attempt + 1,
+			"delay":     delay.String(),
+			"error":     err.Error(),
+		})
+
+		select {
+		case <-ctx.Done():
+			return result, errors.Wrap(ctx.Err(), "waiting to retry")
+		case <-time.After(delay):
+		}
+
+		type findResult struct {
+			value T
+			found bool
+		}
+
+		existing, err := withRetry(ctx, policy, operation+" (check)", func() (findResult, error) {
+			value, found, err := find()
+			return findResult{value: value, found: found}, err
+		})
+		if err != nil {
+			return result, errors.Wrap(err, "checking whether create succeeded")
+		}
+
+		if existing.found {
+			log.Info(ctx, "create succeeded despite transient failure", log.Metadata{
+				"operation": operation,
+			})
+			return existing.value, nil
+		}
+	}
+}
@@ -0,0 +1,187 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/revett/miniflux-sync/log"
+	"github.com/stretchr/testify/require"
+	miniflux "miniflux.app/v2/client"
+)
+
+func TestIsTransient(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		err      error
+		expected bool
+	}{
+		"ConnectionReset": {
+			err:      &url.Error{Op: "Get", URL: "http://miniflux", Err: syscall.ECONNRESET},
+			expected: true,
+		},
+		"UnexpectedEOF": {
+			err:      &url.Error{Op: "Get", URL: "http://miniflux", Err: io.ErrUnexpectedEOF},
+			expected: true,
+		},
+		"InternalServerError": {
+			err:      errors.New("miniflux: internal server error: database is locked"),
+			expected: true,
+		},
+		"BadGateway": {
+			err:      errors.Wrap(errors.New("miniflux: status code=502"), "fetching feeds"),
+			expected: true,
+		},
+		"TooManyRequests": {
+			err:      errors.New("miniflux: status code=429"),
+			expected: true,
+		},
+		"BadRequest": {
+			err:      fmt.Errorf("%w (invalid feed url)", miniflux.ErrBadRequest),
+			expected: false,
+		},
+		"NotFound": {
+			err:      miniflux.ErrNotFound,
+			expected: false,
+		},
+		"Unauthorized": {
+			err:      miniflux.ErrNotAuthorized,
+			expected: false,
+		},
+	}
+
+	for name, testCase := range tests {
+		tc := testCase
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			require.Equal(t, tc.expected, isTransient(tc.err))
+		})
+	}
+}
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	t.Parallel()
+
+	policy := RetryPolicy{
+		InitialBackoff: 100 * time.Millisecond,
+		MaxBackoff:     300 * time.Millisecond,
+	}
+
+	for attempt, maxDelay := range []time.Duration{
+		100 * time.Millisecond, 200 * time.Millisecond, 300 * time.Millisecond, 300 * time.Millisecond,
+	} {
+		delay := policy.backoff(attempt)
+		require.GreaterOrEqual(t, delay, maxDelay/2)
+		require.LessOrEqual(t, delay, maxDelay)
+	}
+}
+
+func TestWithRetry(t *testing.T) {
+	t.Parallel()
+
+	logger := log.New()
+	ctx := logger.WithContext(context.Background())
+	policy := RetryPolicy{MaxRetries: 2}
+
+	t.Run("SucceedsAfterTransientFailures", func(t *testing.T) {
+		t.Parallel()
+
+		calls := 0
+		result, err := withRetry(ctx, policy, "test", func() (int, error) {
+			calls++
+			if calls < 3 {
+				return 0, errors.New("miniflux: status code=503")
+			}
+			return 42, nil
+		})
+
+		require.NoError(t, err)
+		require.Equal(t, 42, result)
+		require.Equal(t, 3, calls)
+	})
+
+	t.Run("GivesUpAfterMaxRetries", func(t *testing.T) {
+		t.Parallel()
+
+		calls := 0
+		_, err := withRetry(ctx, policy, "test", func() (int, error) {
+			calls++
+			return 0, errors.New("miniflux: status code=503")
+		})
+
+		require.Error(t, err)
+		require.Equal(t, 3, calls)
+	})
+
+	t.Run("DoesNotRetryPermanentFailures", func(t *testing.T) {
+		t.Parallel()
+
+		calls := 0
+		_, err := withRetry(ctx, policy, "test", func() (int, error) {
+			calls++
+			return 0, miniflux.ErrBadRequest
+		})
+
+		require.ErrorIs(t, err, miniflux.ErrBadRequest)
+		require.Equal(t, 1, calls)
+	})
+}
+
+func TestCreateWithRetry(t *testing.T) {
+	t.Parallel()
+
+	logger := log.New()
+	ctx := logger.WithContext(context.Background())
+	policy := RetryPolicy{MaxRetries: 2}
+
+	t.Run("UsesExistingAfterLostResponse", func(t *testing.T) {
+		t.Parallel()
+
+		creates := 0
+		result, err := createWithRetry(
+			ctx, policy, "test",
+			func() (int64, error) {
+				creates++
+				return 0, errors.New("miniflux: status code=502")
+			},
+			func() (int64, bool, error) {
+				return 7, true, nil
+			},
+		)
+
+		require.NoError(t, err)
+		require.Equal(t, int64(7), result)
+		require.Equal(t, 1, creates)
+	})
+
+	t.Run("RetriesWhenNotCreated", func(t *testing.T) {
+		t.Parallel()
+
+		creates := 0
+		result, err := createWithRetry(
+			ctx, policy, "test",
+			func() (int64, error) {
+				creates++
+				if creates == 1 {
+					return 0, errors.New("miniflux: status code=502")
+				}
+				return 9, nil
+			},
+			func() (int64, bool, error) {
+				return 0, false, nil
+			},
+		)
+
+		require.NoError(t, err)
+		require.Equal(t, int64(9), result)
+		require.Equal(t, 2, creates)
+	})
+}
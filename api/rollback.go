@@ -0,0 +1,159 @@
+package api
+
+import (
+	"context"
+	"sync"
+
+	"github.com/pkg/errors"
+	"github.com/revett/miniflux-sync/diff"
+	"github.com/revett/miniflux-sync/log"
+	miniflux "miniflux.app/v2/client"
+)
+
+// ErrRollbackFailed is returned by Update when an action failed, and the actions already performed
+// could not all be undone.
+var ErrRollbackFailed = errors.New("rolling back failed")
+
+// undoStep holds the action which undoes a completed action.
+type undoStep struct {
+	actionID string
+	inverse  diff.Action
+}
+
+// undoLog records the undo steps of completed actions, in the order they completed. It is safe
+// for concurrent use.
+type undoLog struct {
+	mu    sync.Mutex
+	steps []undoStep
+}
+
+func (u *undoLog) add(actionID string, inverse diff.Action) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	u.steps = append(u.steps, undoStep{actionID: actionID, inverse: inverse})
+}
+
+// inverseAction returns the action which undoes action. It must be called before action is
+// performed, as it captures the remote state that action changes.
+func inverseAction(data *remoteData, action diff.Action) (diff.Action, error) {
+	switch action.Type {
+	case diff.CreateCategory:
+		return diff.Action{
+			Type:          diff.DeleteCategory,
+			CategoryTitle: action.CategoryTitle,
+		}, nil
+
+	case diff.CreateFeed:
+		return diff.Action{
+			Type:          diff.DeleteFeed,
+			CategoryTitle: action.CategoryTitle,
+			FeedURL:       action.FeedURL,
+		}, nil
+
+	case diff.UpdateFeed:
+		feed, err := data.findFeedByURL(action.FeedURL)
+		if err != nil {
+			return diff.Action{}, err
+		}
+
+		// Every option is set, so options which the update adds are cleared again.
+		return diff.Action{
+			Type:              diff.UpdateFeed,
+			CategoryTitle:     action.CategoryTitle,
+			FeedURL:           action.FeedURL,
+			FeedOptions:       allFeedOptions(feed),
+			RemoteFeedOptions: action.FeedOptions,
+		}, nil
+
+	case diff.DeleteFeed:
+		feed, err := data.findFeedByURL(action.FeedURL)
+		if err != nil {
+			return diff.Action{}, err
+		}
+
+		return diff.Action{
+			Type:          diff.CreateFeed,
+			CategoryTitle: action.CategoryTitle,
+			FeedURL:       action.FeedURL,
+			FeedOptions:   extractFeedOptions(feed),
+		}, nil
+
+	case diff.DeleteCategory:
+		return diff.Action{
+			Type:          diff.CreateCategory,
+			CategoryTitle: action.CategoryTitle,
+		}, nil
+
+	default:
+		return diff.Action{}, errors.Errorf(`unknown action type: "%s"`, action.Type)
+	}
+}
+
+// allFeedOptions returns every configurable option of a Miniflux feed, including empty values.
+func allFeedOptions(feed *miniflux.Feed) diff.FeedOptions {
+	return diff.FeedOptions{
+		Crawler:                     boolPtr(feed.Crawler),
+		Username:                    stringPtr(feed.Username),
+		Password:                    stringPtr(feed.Password),
+		UserAgent:                   stringPtr(feed.UserAgent),
+		Cookie:                      stringPtr(feed.Cookie),
+		Disabled:                    boolPtr(feed.Disabled),
+		IgnoreHTTPCache:             boolPtr(feed.IgnoreHTTPCache),
+		FetchViaProxy:               boolPtr(feed.FetchViaProxy),
+		AllowSelfSignedCertificates: boolPtr(feed.AllowSelfSignedCertificates),
+		DisableHTTP2:                boolPtr(feed.DisableHTTP2),
+		ScraperRules:                stringPtr(feed.ScraperRules),
+		RewriteRules:                stringPtr(feed.RewriteRules),
+		BlocklistRules:              stringPtr(feed.BlocklistRules),
+		KeeplistRules:               stringPtr(feed.KeeplistRules),
+		HideGlobally:                boolPtr(feed.HideGlobally),
+	}
+}
+
+func stringPtr(s string) *string {
+	return &s
+}
+
+// rollback performs the undo steps, most recently completed first, so each inverse runs before the
+// inverses of the actions it depended on. Every step is attempted, even after one fails. The IDs
+// of the actions which were undone are returned.
+func rollback(
+	ctx context.Context,
+	client MinifluxClient,
+	data *remoteData,
+	steps []undoStep,
+	retry RetryPolicy,
+) ([]string, error) {
+	log.Warn(ctx, "rolling back completed actions", log.Metadata{
+		"count": len(steps),
+	})
+
+	undone := []string{}
+
+	for i := len(steps) - 1; i >= 0; i-- {
+		step := steps[i]
+
+		if err := performAction(ctx, client, data, step.inverse, retry); err != nil {
+			log.Error(ctx, errors.Wrap(err, "rolling back action"), log.Metadata{
+				"action": step.actionID,
+			})
+
+			continue
+		}
+
+		undone = append(undone, step.actionID)
+	}
+
+	if len(undone) != len(steps) {
+		return undone, errors.Wrapf(
+			ErrRollbackFailed, "%d of %d actions could not be undone", len(steps)-len(undone), len(steps),
+		)
+	}
+
+	log.Info(ctx, "rolled back completed actions", log.Metadata{
+		"count": len(undone),
+	})
+
+	return undone, nil
+}
@@ -0,0 +1,138 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/revett/miniflux-sync/diff"
+	"github.com/stretchr/testify/require"
+	miniflux "miniflux.app/v2/client"
+)
+
+func TestInverseAction(t *testing.T) { //nolint:funlen
+	t.Parallel()
+
+	enabled := true
+	article := "article"
+	empty := ""
+	disabled := false
+
+	data := &remoteData{
+		feeds: []*miniflux.Feed{
+			{
+				ID:           1,
+				FeedURL:      "https://tech.com/feed",
+				Crawler:      true,
+				ScraperRules: "article",
+				Category:     &miniflux.Category{ID: 1, Title: "Tech"},
+			},
+		},
+		categories: []*miniflux.Category{{ID: 1, Title: "Tech"}},
+	}
+
+	tests := map[string]struct {
+		action   diff.Action
+		expected diff.Action
+		err      string
+	}{
+		"CreateCategory": {
+			action:   diff.Action{Type: diff.CreateCategory, CategoryTitle: "Music"},
+			expected: diff.Action{Type: diff.DeleteCategory, CategoryTitle: "Music"},
+		},
+		"CreateFeed": {
+			action: diff.Action{
+				Type:          diff.CreateFeed,
+				CategoryTitle: "Music",
+				FeedURL:       "https://music.com/feed",
+				FeedOptions:   diff.FeedOptions{Crawler: &enabled},
+			},
+			expected: diff.Action{
+				Type: diff.DeleteFeed, CategoryTitle: "Music", FeedURL: "https://music.com/feed",
+			},
+		},
+		"UpdateFeed": {
+			action: diff.Action{
+				Type:          diff.UpdateFeed,
+				CategoryTitle: "Tech",
+				FeedURL:       "https://tech.com/feed",
+				FeedOptions:   diff.FeedOptions{Disabled: &enabled},
+			},
+			expected: diff.Action{
+				Type:          diff.UpdateFeed,
+				CategoryTitle: "Tech",
+				FeedURL:       "https://tech.com/feed",
+				FeedOptions: diff.FeedOptions{
+					Crawler:                     &enabled,
+					Username:                    &empty,
+					Password:                    &empty,
+					UserAgent:                   &empty,
+					Cookie:                      &empty,
+					Disabled:                    &disabled,
+					IgnoreHTTPCache:             &disabled,
+					FetchViaProxy:               &disabled,
+					AllowSelfSignedCertificates: &disabled,
+					DisableHTTP2:                &disabled,
+					ScraperRules:                &article,
+					RewriteRules:                &empty,
+					BlocklistRules:              &empty,
+					KeeplistRules:               &empty,
+					HideGlobally:                &disabled,
+				},
+				RemoteFeedOptions: diff.FeedOptions{Disabled: &enabled},
+			},
+		},
+		"DeleteFeed": {
+			action: diff.Action{
+				Type: diff.DeleteFeed, CategoryTitle: "Tech", FeedURL: "https://tech.com/feed",
+			},
+			expected: diff.Action{
+				Type:          diff.CreateFeed,
+				CategoryTitle: "Tech",
+				FeedURL:       "https://tech.com/feed",
+				FeedOptions:   extractFeedOptions(data.feeds[0]),
+			},
+		},
+		"DeleteCategory": {
+			action:   diff.Action{Type: diff.DeleteCategory, CategoryTitle: "Tech"},
+			expected: diff.Action{Type: diff.CreateCategory, CategoryTitle: "Tech"},
+		},
+		"UnknownFeed": {
+			action: diff.Action{
+				Type: diff.DeleteFeed, CategoryTitle: "Tech", FeedURL: "https://other.com/feed",
+			},
+			err: `feed not found: "https://other.com/feed"`,
+		},
+	}
+
+	for name, testCase := range tests {
+		tc := testCase
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			inverse, err := inverseAction(data, tc.action)
+			if tc.err != "" {
+				require.EqualError(t, err, tc.err)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, inverse)
+		})
+	}
+}
+
+func TestMarkRolledBack(t *testing.T) {
+	t.Parallel()
+
+	outcomes := []ActionOutcome{
+		{Action: diff.Action{Type: diff.CreateCategory, CategoryTitle: "Music"}, Status: ActionSucceeded},
+		{Action: diff.Action{Type: diff.CreateCategory, CategoryTitle: "Tech"}, Status: ActionSucceeded},
+		{Action: diff.Action{Type: diff.DeleteCategory, CategoryTitle: "Old"}, Status: ActionFailed},
+	}
+
+	markRolledBack(outcomes, []string{`["CreateCategory","Tech",""]`})
+
+	require.Equal(t, ActionSucceeded, outcomes[0].Status)
+	require.Equal(t, ActionRolledBack, outcomes[1].Status)
+	require.Equal(t, ActionFailed, outcomes[2].Status)
+}
@@ -0,0 +1,178 @@
+package api
+
+import (
+	"context"
+	"slices"
+	"sort"
+
+	"github.com/pkg/errors"
+	"github.com/revett/miniflux-sync/diff"
+)
+
+// ActionStatus describes the outcome of an action performed by Update.
+type ActionStatus string
+
+const (
+	// ActionSucceeded means the action was performed successfully.
+	ActionSucceeded ActionStatus = "succeeded"
+
+	// ActionFailed means the action was attempted, and failed.
+	ActionFailed ActionStatus = "failed"
+
+	// ActionSkipped means the action was not attempted.
+	ActionSkipped ActionStatus = "skipped"
+
+	// ActionRolledBack means the action succeeded, and was then undone after another action failed.
+	ActionRolledBack ActionStatus = "rolled back"
+)
+
+var (
+	// ErrDependencyFailed is the reason an action is skipped when an action it depends on failed.
+	ErrDependencyFailed = errors.New("an action it depends on did not succeed")
+
+	// ErrStoppedAfterFailure is the reason an action is skipped when Update stopped early.
+	ErrStoppedAfterFailure = errors.New("stopped after an earlier action failed")
+
+	// ErrCancelled is the reason an action is skipped when the run was cancelled, by a signal or
+	// timeout, before it started.
+	ErrCancelled = errors.New("run was cancelled before it started")
+)
+
+// ActionOutcome holds the outcome of a single action. Err is the failure for failed actions, and
+// the reason for skipped actions.
+type ActionOutcome struct {
+	Action diff.Action
+	Status ActionStatus
+	Err    error
+}
+
+// actionResult is the outcome of performing the action at index.
+type actionResult struct {
+	index int
+	err   error
+}
+
+// schedule calls perform for each action once all of the actions it depends on have succeeded,
+// with up to concurrency calls running at once. Actions must be topologically sorted, and ready
+// actions are started in that order.
+//
+// After a failure, no further actions are started unless keepGoing is set, in which case only the
+// actions depending on the failed one are skipped. Once ctx is done, no further actions are
+// started, but those in flight are waited for. The outcome of every action is returned, in the
+// same order as actions. An error is returned, without performing any action, if the dependencies
+// of the actions are invalid.
+func schedule(
+	ctx context.Context,
+	actions []diff.Action,
+	concurrency int,
+	keepGoing bool,
+	perform func(diff.Action) error,
+) ([]ActionOutcome, error) {
+	graph, err := diff.NewGraph(actions)
+	if err != nil {
+		return nil, errors.Wrap(err, "building dependency graph")
+	}
+
+	outcomes := make([]ActionOutcome, len(actions))
+	unmetDependencies := make([]int, len(actions))
+	ready := []int{}
+
+	for i, action := range actions {
+		outcomes[i].Action = action
+
+		unmetDependencies[i] = len(graph.Dependencies[i])
+		if unmetDependencies[i] == 0 {
+			ready = append(ready, i)
+		}
+	}
+
+	results := make(chan actionResult)
+	running := 0
+	stopped := false
+
+	for {
+		for !stopped && ctx.Err() == nil && running < max(concurrency, 1) && len(ready) > 0 {
+			index := ready[0]
+			ready = ready[1:]
+			running++
+
+			go func() {
+				results <- actionResult{index: index, err: perform(actions[index])}
+			}()
+		}
+
+		if running == 0 {
+			break
+		}
+
+		result := <-results
+		running--
+
+		if result.err != nil {
+			outcomes[result.index].Status = ActionFailed
+			outcomes[result.index].Err = result.err
+			stopped = !keepGoing
+
+			continue
+		}
+
+		outcomes[result.index].Status = ActionSucceeded
+
+		for _, dependentIndex := range graph.Dependents[result.index] {
+			unmetDependencies[dependentIndex]--
+			if unmetDependencies[dependentIndex] == 0 {
+				ready = append(ready, dependentIndex)
+			}
+		}
+
+		sort.Ints(ready)
+	}
+
+	// Any action without an outcome was never started. Actions are sorted, so the outcomes of an
+	// action's dependencies are known before its own.
+	dependencyFailed := make([]bool, len(actions))
+
+	for i := range actions {
+		if outcomes[i].Status != "" {
+			continue
+		}
+
+		for _, dependencyIndex := range graph.Dependencies[i] {
+			if outcomes[dependencyIndex].Status == ActionFailed || dependencyFailed[dependencyIndex] {
+				dependencyFailed[i] = true
+			}
+		}
+
+		outcomes[i].Status = ActionSkipped
+
+		switch {
+		case dependencyFailed[i]:
+			outcomes[i].Err = ErrDependencyFailed
+		case stopped:
+			outcomes[i].Err = ErrStoppedAfterFailure
+		default:
+			outcomes[i].Err = ErrCancelled
+		}
+	}
+
+	return outcomes, nil
+}
+
+// countOutcomes returns the number of outcomes with each status.
+func countOutcomes(outcomes []ActionOutcome) map[ActionStatus]int {
+	counts := map[ActionStatus]int{}
+	for _, outcome := range outcomes {
+		counts[outcome.Status]++
+	}
+
+	return counts
+}
+
+// markRolledBack sets the status of the outcomes for the actions with the given IDs to rolled back.
+func markRolledBack(outcomes []ActionOutcome, actionIDs []string) {
+	for i := range outcomes {
+		if slices.Contains(actionIDs, outcomes[i].Action.ID()) {
+			outcomes[i].Status = ActionRolledBack
+		}
+	}
+}
@@ -0,0 +1,165 @@
+package api
+
+import (
+	"context"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/revett/miniflux-sync/diff"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSchedule(t *testing.T) { //nolint:funlen
+	t.Parallel()
+
+	// CreateFeed:Music depends on CreateCategory:Music, which fails.
+	actions := []diff.Action{
+		{Type: diff.DeleteFeed, CategoryTitle: "Tech", FeedURL: "https://old.com/feed"},
+		{Type: diff.CreateCategory, CategoryTitle: "Music"},
+		{
+			Type:          diff.CreateFeed,
+			CategoryTitle: "Music",
+			FeedURL:       "https://music.com/feed",
+			DependsOn:     []string{`["CreateCategory","Music",""]`},
+		},
+		{Type: diff.UpdateFeed, CategoryTitle: "Tech", FeedURL: "https://tech.com/feed"},
+	}
+
+	errCreate := errors.New("creating category")
+	perform := func(action diff.Action) error {
+		if action.Type == diff.CreateCategory {
+			return errCreate
+		}
+		return nil
+	}
+
+	tests := map[string]struct {
+		keepGoing bool
+		expected  []ActionOutcome
+	}{
+		"StopsAfterFailure": {
+			keepGoing: false,
+			expected: []ActionOutcome{
+				{Action: actions[0], Status: ActionSucceeded},
+				{Action: actions[1], Status: ActionFailed, Err: errCreate},
+				{Action: actions[2], Status: ActionSkipped, Err: ErrDependencyFailed},
+				{Action: actions[3], Status: ActionSkipped, Err: ErrStoppedAfterFailure},
+			},
+		},
+		"KeepGoing": {
+			keepGoing: true,
+			expected: []ActionOutcome{
+				{Action: actions[0], Status: ActionSucceeded},
+				{Action: actions[1], Status: ActionFailed, Err: errCreate},
+				{Action: actions[2], Status: ActionSkipped, Err: ErrDependencyFailed},
+				{Action: actions[3], Status: ActionSucceeded},
+			},
+		},
+	}
+
+	for name, testCase := range tests {
+		tc := testCase
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			// A concurrency of 1 makes the order actions are started in deterministic.
+			outcomes, err := schedule(context.Background(), actions, 1, tc.keepGoing, perform)
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, outcomes)
+		})
+	}
+}
+
+func TestSchedule_Concurrency(t *testing.T) {
+	t.Parallel()
+
+	actions := []diff.Action{}
+	for _, url := range []string{"https://a.com", "https://b.com", "https://c.com", "https://d.com"} {
+		actions = append(actions, diff.Action{Type: diff.UpdateFeed, FeedURL: url})
+	}
+
+	started := make(chan struct{}, len(actions))
+	release := make(chan struct{})
+
+	var outcomes []ActionOutcome
+
+	done := make(chan error)
+	go func() {
+		var err error
+		outcomes, err = schedule(context.Background(), actions, 2, false, func(diff.Action) error {
+			started <- struct{}{}
+			<-release
+			return nil
+		})
+		done <- err
+	}()
+
+	// Exactly two actions start before any are released.
+	<-started
+	<-started
+	require.Empty(t, started)
+
+	close(release)
+
+	require.NoError(t, <-done)
+
+	for _, outcome := range outcomes {
+		require.Equal(t, ActionSucceeded, outcome.Status)
+	}
+}
+
+func TestSchedule_Cancelled(t *testing.T) {
+	t.Parallel()
+
+	actions := []diff.Action{
+		{Type: diff.CreateCategory, CategoryTitle: "Music"},
+		{
+			Type:          diff.CreateFeed,
+			CategoryTitle: "Music",
+			FeedURL:       "https://music.com/feed",
+			DependsOn:     []string{`["CreateCategory","Music",""]`},
+		},
+		{Type: diff.UpdateFeed, CategoryTitle: "Tech", FeedURL: "https://tech.com/feed"},
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// The first action is interrupted whilst in flight, and is finished.
+	outcomes, err := schedule(ctx, actions, 1, false, func(diff.Action) error {
+		cancel()
+		return nil
+	})
+	require.NoError(t, err)
+
+	require.Equal(t, []ActionOutcome{
+		{Action: actions[0], Status: ActionSucceeded},
+		{Action: actions[1], Status: ActionSkipped, Err: ErrCancelled},
+		{Action: actions[2], Status: ActionSkipped, Err: ErrCancelled},
+	}, outcomes)
+}
+
+func TestSchedule_UnknownDependency(t *testing.T) {
+	t.Parallel()
+
+	actions := []diff.Action{
+		{Type: diff.CreateCategory, CategoryTitle: "Music"},
+		{
+			Type:          diff.CreateFeed,
+			CategoryTitle: "Music",
+			FeedURL:       "https://music.com/feed",
+			DependsOn:     []string{`["CreateCategory","News",""]`},
+		},
+	}
+
+	performed := false
+
+	_, err := schedule(context.Background(), actions, 1, false, func(diff.Action) error {
+		performed = true
+		return nil
+	})
+
+	require.ErrorContains(t, err, `depends on unknown action: ["CreateCategory","News",""]`)
+	require.False(t, performed)
+}
@@ -0,0 +1,289 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+	"github.com/revett/miniflux-sync/log"
+)
+
+// redacted replaces secrets in recorded sessions.
+const redacted = "REDACTED"
+
+// secretFields are the JSON fields whose values are redacted from recorded request and response
+// bodies.
+var secretFields = map[string]bool{ //nolint:gochecknoglobals
+	"username": true,
+	"password": true,
+	"cookie":   true,
+}
+
+// recordedHeaders are the response headers kept in recorded sessions, as the others are not used
+// by the Miniflux client.
+var recordedHeaders = []string{"Content-Type", "Retry-After"} //nolint:gochecknoglobals
+
+// Interaction is a request and the response received for it. A session, written by "--record" and
+// read by "--replay", is a file of interactions, with one JSON object per line.
+type Interaction struct {
+	Request  RecordedRequest  `json:"request"`
+	Response RecordedResponse `json:"response"`
+}
+
+// RecordedRequest is a request in a session. The API key is never recorded.
+type RecordedRequest struct {
+	Method string `json:"method"`
+	Path   string `json:"path"`
+	Body   string `json:"body,omitempty"`
+}
+
+// RecordedResponse is a response in a session.
+type RecordedResponse struct {
+	StatusCode int               `json:"status_code"`
+	Headers    map[string]string `json:"headers,omitempty"`
+	Body       string            `json:"body,omitempty"`
+}
+
+// recordingTransport is an http.RoundTripper which records every request and response to a
+// session file. Each interaction is appended to the file once its response is received, so an
+// interrupted run still leaves a usable recording.
+type recordingTransport struct {
+	next http.RoundTripper
+	path string
+
+	mu sync.Mutex
+}
+
+// newRecordingTransport creates a recordingTransport, truncating the file at path so that an
+// unwritable path is reported before any request is sent.
+func newRecordingTransport(next http.RoundTripper, path string) (*recordingTransport, error) {
+	if err := os.WriteFile(path, nil, 0o600); err != nil { //nolint:mnd
+		return nil, errors.Wrap(err, "writing session")
+	}
+
+	return &recordingTransport{
+		next: next,
+		path: path,
+	}, nil
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *recordingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	reqBody, err := readRequestBody(req)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		return nil, err //nolint:wrapcheck
+	}
+
+	respBody, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, errors.Wrap(err, "reading response body")
+	}
+
+	resp.Body = io.NopCloser(bytes.NewReader(respBody))
+
+	headers := map[string]string{}
+	for _, name := range recordedHeaders {
+		if value := resp.Header.Get(name); value != "" {
+			headers[name] = value
+		}
+	}
+
+	if err := t.write(Interaction{
+		Request: RecordedRequest{
+			Method: req.Method,
+			Path:   req.URL.RequestURI(),
+			Body:   redactBody(reqBody),
+		},
+		Response: RecordedResponse{
+			StatusCode: resp.StatusCode,
+			Headers:    headers,
+			Body:       redactBody(respBody),
+		},
+	}); err != nil {
+		log.Error(req.Context(), err, log.Metadata{
+			"path": t.path,
+		})
+	}
+
+	return resp, nil
+}
+
+// write appends an interaction to the session file, as a single line.
+func (t *recordingTransport) write(interaction Interaction) error {
+	dat, err := json.Marshal(interaction)
+	if err != nil {
+		return errors.Wrap(err, "marshalling interaction")
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	file, err := os.OpenFile(t.path, os.O_APPEND|os.O_WRONLY, 0o600) //nolint:mnd
+	if err != nil {
+		return errors.Wrap(err, "opening session")
+	}
+	defer file.Close()
+
+	if _, err := file.Write(append(dat, '\n')); err != nil {
+		return errors.Wrap(err, "writing session")
+	}
+
+	return nil
+}
+
+// replayTransport is an http.RoundTripper which answers requests from a recorded session, without
+// sending anything. Each request is answered by the first unused interaction with the same method,
+// path and body, so repeated requests are answered in the order they were recorded.
+type replayTransport struct {
+	mu           sync.Mutex
+	interactions []Interaction
+	used         []bool
+}
+
+// newReplayTransport creates a replayTransport from the session file at path.
+func newReplayTransport(path string) (*replayTransport, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "reading session")
+	}
+	defer file.Close()
+
+	interactions := []Interaction{}
+
+	decoder := json.NewDecoder(file)
+	for {
+		var interaction Interaction
+
+		err := decoder.Decode(&interaction)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		if err != nil {
+			return nil, errors.Wrapf(err, "parsing interaction %d of session", len(interactions)+1)
+		}
+
+		interactions = append(interactions, interaction)
+	}
+
+	return &replayTransport{
+		interactions: interactions,
+		used:         make([]bool, len(interactions)),
+	}, nil
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *replayTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	body, err := readRequestBody(req)
+	if err != nil {
+		return nil, err
+	}
+
+	// Recorded bodies are redacted, so the request body is redacted the same way to match them.
+	recorded := RecordedRequest{
+		Method: req.Method,
+		Path:   req.URL.RequestURI(),
+		Body:   redactBody(body),
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for i, interaction := range t.interactions {
+		if t.used[i] || interaction.Request != recorded {
+			continue
+		}
+
+		t.used[i] = true
+
+		return newReplayedResponse(req, interaction.Response), nil
+	}
+
+	return nil, errors.Errorf(
+		"no recorded response for %s %s in session", recorded.Method, recorded.Path,
+	)
+}
+
+func newReplayedResponse(req *http.Request, recorded RecordedResponse) *http.Response {
+	header := http.Header{}
+	for name, value := range recorded.Headers {
+		header.Set(name, value)
+	}
+
+	return &http.Response{
+		Status:        http.StatusText(recorded.StatusCode),
+		StatusCode:    recorded.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(strings.NewReader(recorded.Body)),
+		ContentLength: int64(len(recorded.Body)),
+		Request:       req,
+	}
+}
+
+// readRequestBody reads the body of req, replacing it so that it can still be sent.
+func readRequestBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, nil
+	}
+
+	body, err := io.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return nil, errors.Wrap(err, "reading request body")
+	}
+
+	req.Body = io.NopCloser(bytes.NewReader(body))
+
+	return body, nil
+}
+
+// redactBody replaces the values of secret fields in a JSON body. Bodies which are not JSON, such
+// as the healthcheck response, are returned unchanged.
+func redactBody(body []byte) string {
+	var v any
+	if err := json.Unmarshal(body, &v); err != nil {
+		return string(body)
+	}
+
+	dat, err := json.Marshal(redactValue(v))
+	if err != nil {
+		return string(body)
+	}
+
+	return string(dat)
+}
+
+func redactValue(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		for key, value := range v {
+			if s, ok := value.(string); ok && secretFields[key] && s != "" {
+				v[key] = redacted
+				continue
+			}
+
+			v[key] = redactValue(value)
+		}
+
+	case []any:
+		for i, value := range v {
+			v[i] = redactValue(value)
+		}
+	}
+
+	return v
+}
@@ -0,0 +1,135 @@
+package api
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/revett/miniflux-sync/log"
+	"github.com/revett/miniflux-sync/minifluxtest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedactBody(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		body     string
+		expected string
+	}{
+		"NotJSON": {
+			body:     "OK",
+			expected: "OK",
+		},
+		"Object": {
+			body:     `{"feed_url":"https://example.com","password":"hunter2","cookie":"a=b"}`,
+			expected: `{"cookie":"REDACTED","feed_url":"https://example.com","password":"REDACTED"}`,
+		},
+		"NestedArray": {
+			body:     `[{"id":1,"password":"hunter2","category":{"title":"news"}}]`,
+			expected: `[{"category":{"title":"news"},"id":1,"password":"REDACTED"}]`,
+		},
+		"User": {
+			body:     `{"id":1,"username":"alice","is_admin":true}`,
+			expected: `{"id":1,"is_admin":true,"username":"REDACTED"}`,
+		},
+		"EmptySecret": {
+			body:     `{"password":""}`,
+			expected: `{"password":""}`,
+		},
+	}
+
+	for name, testCase := range tests {
+		tc := testCase
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			require.Equal(t, tc.expected, redactBody([]byte(tc.body)))
+		})
+	}
+}
+
+func TestRecordAndReplay(t *testing.T) {
+	t.Parallel()
+
+	server := minifluxtest.NewServer()
+	defer server.Close()
+
+	category := server.AddCategory("news")
+
+	logger := log.New()
+	ctx := logger.WithContext(context.Background())
+
+	path := filepath.Join(t.TempDir(), "session.jsonl")
+
+	recording, err := newRecordingTransport(http.DefaultTransport, path)
+	require.NoError(t, err)
+
+	createFeed := `{"feed_url":"https://example.com/feed.xml","category_id":` +
+		strconv.FormatInt(category.ID, 10) + `,"username":"alice","password":"hunter2"}`
+
+	send := func(t *testing.T, transport http.RoundTripper, method, path, body string) (int, string) {
+		t.Helper()
+
+		req, err := http.NewRequestWithContext(ctx, method, server.URL+path, strings.NewReader(body))
+		require.NoError(t, err)
+		req.Header.Set("X-Auth-Token", minifluxtest.APIKey)
+
+		resp, err := (&http.Client{Transport: transport}).Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		dat, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+
+		return resp.StatusCode, string(dat)
+	}
+
+	createStatus, createBody := send(t, recording, http.MethodPost, "/v1/feeds", createFeed)
+	require.Equal(t, http.StatusCreated, createStatus)
+
+	feedsStatus, feedsBody := send(t, recording, http.MethodGet, "/v1/feeds", "")
+	require.Equal(t, http.StatusOK, feedsStatus)
+
+	meStatus, meBody := send(t, recording, http.MethodGet, "/v1/me", "")
+	require.Equal(t, http.StatusOK, meStatus)
+	require.Contains(t, meBody, minifluxtest.Username)
+
+	session, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.NotContains(t, string(session), "hunter2")
+	require.NotContains(t, string(session), "alice")
+	require.NotContains(t, string(session), minifluxtest.Username)
+	require.NotContains(t, string(session), minifluxtest.APIKey)
+	require.Contains(t, string(session), redacted)
+
+	// Each interaction is written as a single line.
+	require.Len(t, strings.Split(strings.TrimSuffix(string(session), "\n"), "\n"), 3)
+
+	replay, err := newReplayTransport(path)
+	require.NoError(t, err)
+
+	// Requests are answered from the recording, even once the server has gone.
+	server.Close()
+
+	status, body := send(t, replay, http.MethodGet, "/v1/feeds", "")
+	require.Equal(t, feedsStatus, status)
+	require.Equal(t, redactBody([]byte(feedsBody)), body)
+
+	status, body = send(t, replay, http.MethodPost, "/v1/feeds", createFeed)
+	require.Equal(t, createStatus, status)
+	require.JSONEq(t, createBody, body)
+
+	// Each recorded response is only used once.
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+"/v1/feeds", nil)
+	require.NoError(t, err)
+
+	_, err = replay.RoundTrip(req)
+	require.ErrorContains(t, err, "no recorded response for GET /v1/feeds")
+}
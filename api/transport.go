@@ -0,0 +1,382 @@
+package api
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/revett/miniflux-sync/config"
+	"github.com/revett/miniflux-sync/log"
+	"golang.org/x/time/rate"
+)
+
+// unixEndpointPrefix is the prefix of endpoints which are unix sockets.
+const unixEndpointPrefix = "unix://"
+
+// newTransport returns the HTTP transport for the Miniflux API requests of a single client, which
+// records or replays them, limits how long each can take, and rate limits them.
+func newTransport(ctx context.Context, cfg *config.GlobalFlags) (http.RoundTripper, error) {
+	transport, err := newBaseTransport(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case cfg.Record != "" && cfg.Replay != "":
+		return nil, errors.New(`"--record" and "--replay" cannot be used together`)
+
+	case cfg.Record != "":
+		log.Info(ctx, "recording miniflux api requests", log.Metadata{
+			"path": cfg.Record,
+		})
+
+		recording, err := newRecordingTransport(transport, cfg.Record)
+		if err != nil {
+			return nil, err
+		}
+
+		transport = recording
+
+	case cfg.Replay != "":
+		log.Info(ctx, "replaying miniflux api requests, the instance is not contacted", log.Metadata{
+			"path": cfg.Replay,
+		})
+
+		replay, err := newReplayTransport(cfg.Replay)
+		if err != nil {
+			return nil, err
+		}
+
+		transport = replay
+	}
+
+	transport = newTimeoutTransport(transport, cfg.RequestTimeout)
+
+	return newRateLimitedTransport(transport, cfg.Rate, cfg.Burst), nil
+}
+
+// newBaseTransport returns the transport which sends requests to the Miniflux endpoint. Without
+// TLS, proxy or unix socket settings, this is http.DefaultTransport, so its connections are shared,
+// and otherwise the settings are applied to a copy of it.
+func newBaseTransport(ctx context.Context, cfg *config.GlobalFlags) (http.RoundTripper, error) {
+	transport, err := configuredTransport(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(cfg.Headers) == 0 {
+		return transport, nil
+	}
+
+	header := http.Header{}
+	for name, value := range cfg.Headers {
+		header.Set(name, value)
+	}
+
+	return &headerTransport{next: transport, header: header}, nil
+}
+
+// configuredTransport returns http.DefaultTransport, or a copy of it with the TLS, proxy and unix
+// socket settings applied, if any are set.
+func configuredTransport(ctx context.Context, cfg *config.GlobalFlags) (http.RoundTripper, error) {
+	tlsConfig, err := newTLSConfig(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	socketPath, isSocket := unixSocketPath(cfg.Endpoint)
+
+	base, ok := http.DefaultTransport.(*http.Transport)
+	if !ok || (tlsConfig == nil && !isSocket && cfg.Proxy == "") {
+		return http.DefaultTransport, nil
+	}
+
+	transport := base.Clone()
+
+	if tlsConfig != nil {
+		transport.TLSClientConfig = tlsConfig
+	}
+
+	if isSocket {
+		if cfg.Proxy != "" {
+			return nil, errors.New(`"--proxy" cannot be used with a unix socket endpoint`)
+		}
+
+		log.Info(ctx, "connecting to miniflux over unix socket", log.Metadata{
+			"socket": socketPath,
+		})
+
+		transport.Proxy = nil
+		transport.DialContext = func(ctx context.Context, _, _ string) (net.Conn, error) {
+			var dialer net.Dialer
+			return dialer.DialContext(ctx, "unix", socketPath)
+		}
+	}
+
+	if cfg.Proxy != "" {
+		proxyURL, err := url.Parse(cfg.Proxy)
+		if err != nil {
+			return nil, errors.Wrap(err, "parsing proxy url")
+		}
+
+		log.Info(ctx, "using proxy for miniflux api requests", log.Metadata{
+			"proxy": proxyURL.Redacted(),
+		})
+
+		transport.Proxy = http.ProxyURL(proxyURL)
+	}
+
+	return transport, nil
+}
+
+// unixSocketPath returns the path of the socket for an endpoint of the form
+// "unix:///run/miniflux.sock".
+func unixSocketPath(endpoint string) (string, bool) {
+	path, ok := strings.CutPrefix(endpoint, unixEndpointPrefix)
+	if !ok || path == "" {
+		return "", false
+	}
+
+	return path, true
+}
+
+// clientEndpoint returns the endpoint given to the Miniflux client. For a unix socket, this is a
+// placeholder HTTP URL, as the transport dials the socket whatever the host.
+func clientEndpoint(endpoint string) string {
+	if _, ok := unixSocketPath(endpoint); ok {
+		return "http://unix"
+	}
+
+	return endpoint
+}
+
+// newTLSConfig returns the TLS configuration for the Miniflux endpoint, or nil if the defaults are
+// used.
+func newTLSConfig(ctx context.Context, cfg *config.GlobalFlags) (*tls.Config, error) {
+	if cfg.CACert == "" && cfg.ClientCert == "" && !cfg.Insecure {
+		return nil, nil //nolint:nilnil
+	}
+
+	tlsConfig := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+	}
+
+	if cfg.CACert != "" {
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+
+		pem, err := os.ReadFile(cfg.CACert)
+		if err != nil {
+			return nil, errors.Wrap(err, "reading ca certificates")
+		}
+
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, errors.Errorf(`no certificates found in "%s"`, cfg.CACert)
+		}
+
+		tlsConfig.RootCAs = pool
+	}
+
+	if cfg.ClientCert != "" {
+		cert, err := tls.LoadX509KeyPair(cfg.ClientCert, cfg.ClientKey)
+		if err != nil {
+			return nil, errors.Wrap(err, "loading client certificate")
+		}
+
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	if cfg.Insecure {
+		log.Warn(ctx, "TLS CERTIFICATE VERIFICATION IS DISABLED, connections to miniflux can be "+
+			"intercepted, and the api key stolen. Use \"--ca-cert\" for a private CA instead.")
+
+		tlsConfig.InsecureSkipVerify = true //nolint:gosec
+	}
+
+	return tlsConfig, nil
+}
+
+// headerTransport is an http.RoundTripper which adds extra headers to every request.
+type headerTransport struct {
+	next   http.RoundTripper
+	header http.Header
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *headerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// A RoundTripper must not modify the request it is given.
+	req = req.Clone(req.Context())
+
+	for name, values := range t.header {
+		req.Header[name] = values
+	}
+
+	return t.next.RoundTrip(req) //nolint:wrapcheck
+}
+
+// timeoutTransport is an http.RoundTripper which limits how long each request can take, including
+// reading the response body. Each client also limits every request to defaultRequestTimeout.
+type timeoutTransport struct {
+	next    http.RoundTripper
+	timeout time.Duration
+}
+
+// newTimeoutTransport creates a timeoutTransport. A timeout of zero disables the limit.
+func newTimeoutTransport(next http.RoundTripper, timeout time.Duration) *timeoutTransport {
+	return &timeoutTransport{
+		next:    next,
+		timeout: timeout,
+	}
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *timeoutTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if t.timeout <= 0 {
+		return t.next.RoundTrip(req) //nolint:wrapcheck
+	}
+
+	ctx, cancel := context.WithTimeout(req.Context(), t.timeout)
+
+	resp, err := t.next.RoundTrip(req.WithContext(ctx))
+	if err != nil {
+		cancel()
+		return nil, err //nolint:wrapcheck
+	}
+
+	// The timeout also covers reading the body, so it is only released once the body is closed.
+	resp.Body = &cancelOnClose{ReadCloser: resp.Body, cancel: cancel}
+
+	return resp, nil
+}
+
+// cancelOnClose is a response body which cancels the request context once it is closed.
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+// Close implements io.Closer.
+func (c *cancelOnClose) Close() error {
+	defer c.cancel()
+	return c.ReadCloser.Close() //nolint:wrapcheck
+}
+
+// rateLimitedTransport is an http.RoundTripper which waits for a token bucket limiter before each
+// request. When a response includes a Retry-After header, every request waits until that time has
+// passed, so retries are not sent before the server is ready for them.
+type rateLimitedTransport struct {
+	next    http.RoundTripper
+	limiter *rate.Limiter
+
+	mu          sync.Mutex
+	pausedUntil time.Time
+}
+
+// newRateLimitedTransport creates a rateLimitedTransport allowing requestsPerSecond requests, with
+// bursts of up to burst requests. A requestsPerSecond of zero disables the limit, though
+// Retry-After headers are still honoured.
+func newRateLimitedTransport(
+	next http.RoundTripper, requestsPerSecond float64, burst int,
+) *rateLimitedTransport {
+	limit := rate.Inf
+	if requestsPerSecond > 0 {
+		limit = rate.Limit(requestsPerSecond)
+	}
+
+	return &rateLimitedTransport{
+		next:    next,
+		limiter: rate.NewLimiter(limit, max(burst, 1)),
+	}
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *rateLimitedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if err := t.waitForPause(req.Context()); err != nil {
+		return nil, err
+	}
+
+	if err := t.limiter.Wait(req.Context()); err != nil {
+		return nil, errors.Wrap(err, "waiting for rate limiter")
+	}
+
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		return nil, err //nolint:wrapcheck
+	}
+
+	if resp.StatusCode == http.StatusTooManyRequests ||
+		resp.StatusCode == http.StatusServiceUnavailable {
+		if delay, ok := parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()); ok {
+			t.pause(req.Context(), delay)
+		}
+	}
+
+	return resp, nil
+}
+
+// pause makes every request wait until delay has passed.
+func (t *rateLimitedTransport) pause(ctx context.Context, delay time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	until := time.Now().Add(delay)
+	if until.After(t.pausedUntil) {
+		t.pausedUntil = until
+	}
+
+	log.Warn(ctx, "miniflux asked to retry later, pausing requests", log.Metadata{
+		"delay": delay.String(),
+	})
+}
+
+func (t *rateLimitedTransport) waitForPause(ctx context.Context) error {
+	t.mu.Lock()
+	delay := time.Until(t.pausedUntil)
+	t.mu.Unlock()
+
+	if delay <= 0 {
+		return nil
+	}
+
+	select {
+	case <-ctx.Done():
+		return errors.Wrap(ctx.Err(), "waiting for retry-after")
+	case <-time.After(delay):
+		return nil
+	}
+}
+
+// parseRetryAfter parses the value of a Retry-After header, which is either a number of seconds or
+// an HTTP date, returning the delay from now.
+func parseRetryAfter(value string, now time.Time) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+
+		return time.Duration(seconds) * time.Second, true
+	}
+
+	date, err := http.ParseTime(value)
+	if err != nil {
+		return 0, false
+	}
+
+	return max(date.Sub(now), 0), true
+}
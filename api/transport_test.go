@@ -0,0 +1,394 @@
+package api
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/revett/miniflux-sync/config"
+	"github.com/revett/miniflux-sync/log"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRetryAfter(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := map[string]struct {
+		value    string
+		expected time.Duration
+		ok       bool
+	}{
+		"Seconds": {
+			value:    "120",
+			expected: 2 * time.Minute,
+			ok:       true,
+		},
+		"HTTPDate": {
+			value:    "Mon, 01 Jan 2024 12:00:30 GMT",
+			expected: 30 * time.Second,
+			ok:       true,
+		},
+		"HTTPDateInPast": {
+			value:    "Mon, 01 Jan 2024 11:00:00 GMT",
+			expected: 0,
+			ok:       true,
+		},
+		"Empty": {
+			value: "",
+			ok:    false,
+		},
+		"NegativeSeconds": {
+			value: "-1",
+			ok:    false,
+		},
+		"Invalid": {
+			value: "soon",
+			ok:    false,
+		},
+	}
+
+	for name, testCase := range tests {
+		tc := testCase
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			delay, ok := parseRetryAfter(tc.value, now)
+			require.Equal(t, tc.ok, ok)
+			require.Equal(t, tc.expected, delay)
+		})
+	}
+}
+
+func TestRateLimitedTransport(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	logger := log.New()
+	ctx := logger.WithContext(context.Background())
+
+	// 20 requests per second with no burst allows one request every 50ms.
+	transport := newRateLimitedTransport(http.DefaultTransport, 20, 1)
+	client := &http.Client{Transport: transport}
+
+	start := time.Now()
+	for range 3 {
+		resp, err := client.Do(newRequest(ctx, t, server.URL))
+		require.NoError(t, err)
+		require.NoError(t, resp.Body.Close())
+	}
+
+	require.GreaterOrEqual(t, time.Since(start), 90*time.Millisecond)
+}
+
+func TestRateLimitedTransport_RetryAfter(t *testing.T) {
+	t.Parallel()
+
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		requests++
+		if requests == 1 {
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	logger := log.New()
+	ctx := logger.WithContext(context.Background())
+
+	transport := newRateLimitedTransport(http.DefaultTransport, 0, 1)
+	client := &http.Client{Transport: transport}
+
+	// The pause is logged using the logger of the request context.
+	resp, err := client.Do(newRequest(ctx, t, server.URL))
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+	require.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+
+	// The next request waits until the Retry-After delay has passed.
+	start := time.Now()
+	resp, err = client.Do(newRequest(ctx, t, server.URL))
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.GreaterOrEqual(t, time.Since(start), 900*time.Millisecond)
+}
+
+// newRequest returns a GET request for url, with ctx.
+func newRequest(ctx context.Context, t *testing.T, url string) *http.Request {
+	t.Helper()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	require.NoError(t, err)
+
+	return req
+}
+
+// writePEM writes a PEM block to a file in dir, returning its path.
+func writePEM(t *testing.T, dir string, name string, blockType string, der []byte) string {
+	t.Helper()
+
+	path := filepath.Join(dir, name)
+	require.NoError(t, os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0o600))
+
+	return path
+}
+
+// writeClientCertificate writes a self-signed client certificate and its key to dir.
+func writeClientCertificate(t *testing.T, dir string) (string, string) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "miniflux-sync"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+
+	cert, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	return writePEM(t, dir, "client.crt", "CERTIFICATE", cert),
+		writePEM(t, dir, "client.key", "EC PRIVATE KEY", keyDER)
+}
+
+func TestNewBaseTransport_TLS(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if len(r.TLS.PeerCertificates) == 0 {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+	}))
+	server.TLS = &tls.Config{ClientAuth: tls.RequestClientCert} //nolint:gosec
+	server.StartTLS()
+	t.Cleanup(server.Close)
+
+	dir := t.TempDir()
+	caCert := writePEM(t, dir, "ca.crt", "CERTIFICATE", server.Certificate().Raw)
+	clientCert, clientKey := writeClientCertificate(t, dir)
+
+	tests := map[string]struct {
+		cfg        config.GlobalFlags
+		statusCode int
+		err        string
+	}{
+		"UnknownCA": {
+			err: "certificate",
+		},
+		"CACert": {
+			cfg:        config.GlobalFlags{CACert: caCert},
+			statusCode: http.StatusUnauthorized,
+		},
+		"ClientCertificate": {
+			cfg:        config.GlobalFlags{CACert: caCert, ClientCert: clientCert, ClientKey: clientKey},
+			statusCode: http.StatusOK,
+		},
+		"InsecureSkipVerify": {
+			cfg:        config.GlobalFlags{Insecure: true},
+			statusCode: http.StatusUnauthorized,
+		},
+	}
+
+	for name, testCase := range tests {
+		tc := testCase
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			logger := log.New()
+			ctx := logger.WithContext(context.Background())
+
+			transport, err := newBaseTransport(ctx, &tc.cfg)
+			require.NoError(t, err)
+
+			resp, err := (&http.Client{Transport: transport}).Get(server.URL)
+			if tc.err != "" {
+				require.ErrorContains(t, err, tc.err)
+				return
+			}
+
+			require.NoError(t, err)
+			require.NoError(t, resp.Body.Close())
+			require.Equal(t, tc.statusCode, resp.StatusCode)
+		})
+	}
+}
+
+func TestNewBaseTransport_InvalidFiles(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	notPEM := filepath.Join(dir, "not.pem")
+	require.NoError(t, os.WriteFile(notPEM, []byte("not a certificate"), 0o600))
+
+	logger := log.New()
+	ctx := logger.WithContext(context.Background())
+
+	_, err := newBaseTransport(ctx, &config.GlobalFlags{CACert: notPEM})
+	require.ErrorContains(t, err, "no certificates found")
+
+	_, err = newBaseTransport(ctx, &config.GlobalFlags{CACert: filepath.Join(dir, "missing.pem")})
+	require.ErrorContains(t, err, "reading ca certificates")
+
+	_, err = newBaseTransport(ctx, &config.GlobalFlags{ClientCert: notPEM, ClientKey: notPEM})
+	require.ErrorContains(t, err, "loading client certificate")
+}
+
+func TestNewBaseTransport_ProxyAndHeaders(t *testing.T) {
+	t.Parallel()
+
+	var (
+		requestURL string
+		header     http.Header
+	)
+
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestURL = r.URL.String()
+		header = r.Header.Clone()
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer proxy.Close()
+
+	logger := log.New()
+	ctx := logger.WithContext(context.Background())
+
+	transport, err := newBaseTransport(ctx, &config.GlobalFlags{
+		Proxy: proxy.URL,
+		Headers: map[string]string{
+			"Cf-Access-Client-Id":     "id",
+			"Cf-Access-Client-Secret": "secret",
+		},
+	})
+	require.NoError(t, err)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://miniflux.invalid/v1/feeds", nil)
+	require.NoError(t, err)
+	req.Header.Set("X-Auth-Token", "key")
+
+	resp, err := (&http.Client{Transport: transport}).Do(req)
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+
+	require.Equal(t, "http://miniflux.invalid/v1/feeds", requestURL)
+	require.Equal(t, "id", header.Get("Cf-Access-Client-Id"))
+	require.Equal(t, "secret", header.Get("Cf-Access-Client-Secret"))
+	require.Equal(t, "key", header.Get("X-Auth-Token"))
+
+	// The request given to the transport is not modified.
+	require.Empty(t, req.Header.Get("Cf-Access-Client-Id"))
+}
+
+func TestUnixSocketPath(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		endpoint string
+		path     string
+		ok       bool
+		client   string
+	}{
+		"Absolute": {
+			endpoint: "unix:///run/miniflux.sock",
+			path:     "/run/miniflux.sock",
+			ok:       true,
+			client:   "http://unix",
+		},
+		"Relative": {
+			endpoint: "unix://miniflux.sock",
+			path:     "miniflux.sock",
+			ok:       true,
+			client:   "http://unix",
+		},
+		"HTTP": {
+			endpoint: "https://miniflux.example.com",
+			client:   "https://miniflux.example.com",
+		},
+		"Empty": {
+			endpoint: "unix://",
+			client:   "unix://",
+		},
+	}
+
+	for name, testCase := range tests {
+		tc := testCase
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			path, ok := unixSocketPath(tc.endpoint)
+			require.Equal(t, tc.ok, ok)
+			require.Equal(t, tc.path, path)
+			require.Equal(t, tc.client, clientEndpoint(tc.endpoint))
+		})
+	}
+}
+
+func TestNewBaseTransport_UnixSocket(t *testing.T) {
+	t.Parallel()
+
+	socket := filepath.Join(t.TempDir(), "miniflux.sock")
+
+	listener, err := net.Listen("unix", socket)
+	require.NoError(t, err)
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(r.URL.Path))
+	}))
+	server.Listener = listener
+	server.Start()
+	defer server.Close()
+
+	logger := log.New()
+	ctx := logger.WithContext(context.Background())
+
+	endpoint := "unix://" + socket
+
+	transport, err := newBaseTransport(ctx, &config.GlobalFlags{Endpoint: endpoint})
+	require.NoError(t, err)
+
+	resp, err := (&http.Client{Transport: transport}).Get(clientEndpoint(endpoint) + "/healthcheck")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	require.Equal(t, "/healthcheck", string(body))
+
+	_, err = newBaseTransport(ctx, &config.GlobalFlags{Endpoint: endpoint, Proxy: "http://proxy:3128"})
+	require.EqualError(t, err, `"--proxy" cannot be used with a unix socket endpoint`)
+}
@@ -2,127 +2,421 @@ package api
 
 import (
 	"context"
+	"slices"
+	"sync"
 
 	"github.com/pkg/errors"
 	"github.com/revett/miniflux-sync/diff"
+	"github.com/revett/miniflux-sync/journal"
 	"github.com/revett/miniflux-sync/log"
 	miniflux "miniflux.app/v2/client"
 )
 
-// Update performs a series of actions on the Miniflux instance.
-func Update( //nolint:cyclop,funlen
+// UpdateOptions configures how Update performs actions.
+type UpdateOptions struct {
+	// Concurrency is the maximum number of actions to perform at once. Values below 1 are treated
+	// as 1, which performs every action serially.
+	Concurrency int
+
+	// KeepGoing continues after an action fails, skipping only the actions which depend on it.
+	KeepGoing bool
+
+	// RollbackOnFailure undoes the actions already performed if any action fails, most recently
+	// completed first.
+	RollbackOnFailure bool
+
+	// Retry configures how transient Miniflux API failures are retried.
+	Retry RetryPolicy
+
+	// Journal records the start and outcome of each action, so an interrupted sync can be
+	// resumed. A nil Journal records nothing.
+	Journal *journal.Journal
+}
+
+// Update performs a series of actions on the Miniflux instance. Each action is started once the
+// actions it depends on have completed, with up to opts.Concurrency actions running at once.
+// The outcome of every action is returned, in the order they were scheduled, along with an error
+// if any action failed. If opts.RollbackOnFailure is set, a failure undoes the actions which
+// succeeded, and ErrRollbackFailed is returned if any of them could not be undone.
+func Update(
 	ctx context.Context,
-	client *miniflux.Client,
+	client MinifluxClient,
 	actions []diff.Action,
 	feeds []*miniflux.Feed,
 	categories []*miniflux.Category,
-) error {
-	log.Info(ctx, "performing actions")
+	opts UpdateOptions,
+) ([]ActionOutcome, error) {
+	log.Info(ctx, "performing actions", log.Metadata{
+		"concurrency": max(opts.Concurrency, 1),
+		"keep_going":  opts.KeepGoing,
+		"rollback":    opts.RollbackOnFailure,
+	})
+
+	sorted, err := diff.TopologicalSort(actions)
+	if err != nil {
+		return nil, errors.Wrap(err, "ordering actions")
+	}
 
-	for _, action := range actions {
-		switch action.Type {
-		case diff.CreateCategory:
-			log.Info(ctx, "creating category", log.Metadata{
-				"title": action.CategoryTitle,
-			})
+	data := &remoteData{
+		feeds:      feeds,
+		categories: categories,
+	}
+
+	undo := &undoLog{}
+
+	outcomes, err := schedule(ctx, sorted, opts.Concurrency, opts.KeepGoing, func(action diff.Action) error {
+		actionCtx, cancel := actionContext(ctx)
+		defer cancel()
 
-			category, err := client.CreateCategory(action.CategoryTitle)
+		var inverse diff.Action
+		if opts.RollbackOnFailure {
+			captured, err := inverseAction(data, action)
 			if err != nil {
-				return errors.Wrap(err, "creating category")
+				return errors.Wrap(err, "capturing inverse action")
 			}
 
-			categories = append(categories, category)
+			inverse = captured
+		}
 
-		case diff.CreateFeed:
-			log.Info(ctx, "creating feed", log.Metadata{
+		err := performJournaledAction(actionCtx, client, data, action, opts)
+		if err != nil && opts.KeepGoing {
+			log.Error(ctx, err, log.Metadata{
+				"action":   action.ID(),
 				"category": action.CategoryTitle,
 				"url":      action.FeedURL,
 			})
+		}
 
-			categoryID, err := findCategoryIDByTitle(action.CategoryTitle, categories)
-			if err != nil {
-				return errors.Wrap(err, "finding category id")
-			}
+		if err == nil && opts.RollbackOnFailure {
+			undo.add(action.ID(), inverse)
+		}
 
-			req := miniflux.FeedCreationRequest{
-				FeedURL:    action.FeedURL,
-				CategoryID: categoryID,
-			}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
 
-			applyOptionsToCreationRequest(&req, action.FeedOptions)
+	counts := countOutcomes(outcomes)
+	if counts[ActionFailed] == 0 && counts[ActionSkipped] > 0 && ctx.Err() != nil {
+		return outcomes, errors.Wrapf(
+			ctx.Err(), "stopped with %d of %d actions not started", counts[ActionSkipped], len(outcomes),
+		)
+	}
 
-			feedID, err := client.CreateFeed(&req)
-			if err != nil {
-				return errors.Wrap(err, "creating feed")
-			}
+	if counts[ActionFailed] == 0 {
+		return outcomes, nil
+	}
 
-			feed, err := client.Feed(feedID)
-			if err != nil {
-				return errors.Wrap(err, "fetching feed")
+	if opts.RollbackOnFailure {
+		// The rollback is performed even if the run was cancelled, to not leave a partial sync.
+		undone, err := rollback(
+			context.WithoutCancel(ctx), client, data, undo.steps, opts.Retry,
+		)
+		markRolledBack(outcomes, undone)
+
+		if err != nil {
+			return outcomes, err
+		}
+	}
+
+	if !opts.KeepGoing {
+		for _, outcome := range outcomes {
+			if outcome.Status == ActionFailed {
+				return outcomes, outcome.Err
 			}
+		}
+	}
 
-			feeds = append(feeds, feed)
+	return outcomes, errors.Errorf(
+		"%d of %d actions failed, %d skipped",
+		counts[ActionFailed], len(outcomes), counts[ActionSkipped],
+	)
+}
 
-		case diff.UpdateFeed:
-			log.Info(ctx, "updating feed", log.Metadata{
-				"category": action.CategoryTitle,
-				"url":      action.FeedURL,
-			})
+// actionContext returns the context for performing a single action. It is not cancelled along with
+// ctx, so an action in flight when the run is interrupted is finished, but it keeps the deadline of
+// ctx, so the action still stops once the run times out.
+func actionContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	detached := context.WithoutCancel(ctx)
 
-			feedID, err := findFeedIDByURL(action.FeedURL, feeds)
-			if err != nil {
-				return errors.Wrap(err, "finding feed id")
-			}
+	if deadline, ok := ctx.Deadline(); ok {
+		return context.WithDeadline(detached, deadline)
+	}
 
-			modReq := miniflux.FeedModificationRequest{}
-			applyOptionsToModificationRequest(&modReq, action.FeedOptions)
+	return context.WithCancel(detached)
+}
 
-			_, err = client.UpdateFeed(feedID, &modReq)
-			if err != nil {
-				return errors.Wrap(err, "updating feed")
-			}
+// performJournaledAction performs a single action, recording it in the journal. The start of the
+// action is recorded before any request is made, so an interrupted action can be reconciled.
+func performJournaledAction(
+	ctx context.Context,
+	client MinifluxClient,
+	data *remoteData,
+	action diff.Action,
+	opts UpdateOptions,
+) error {
+	if err := opts.Journal.Start(action); err != nil {
+		return errors.Wrap(err, "recording action start")
+	}
 
-		case diff.DeleteCategory:
-			log.Info(ctx, "deleting category", log.Metadata{
-				"title": action.CategoryTitle,
-			})
+	if err := performAction(ctx, client, data, action, opts.Retry); err != nil {
+		if journalErr := opts.Journal.Fail(action, err); journalErr != nil {
+			log.Error(ctx, errors.Wrap(journalErr, "recording action failure"))
+		}
 
-			categoryID, err := findCategoryIDByTitle(action.CategoryTitle, categories)
-			if err != nil {
-				return errors.Wrap(err, "finding category id")
-			}
+		return err
+	}
 
-			if err := client.DeleteCategory(categoryID); err != nil {
-				return errors.Wrap(err, "deleting category")
-			}
+	if err := opts.Journal.Complete(action); err != nil {
+		return errors.Wrap(err, "recording action completion")
+	}
 
-			categories = removeCategoryByID(categoryID, categories)
+	return nil
+}
 
-		case diff.DeleteFeed:
-			log.Info(ctx, "deleting feed", log.Metadata{
-				"category": action.CategoryTitle,
-				"url":      action.FeedURL,
-			})
+// deleteCreatedFeed deletes a feed which was created by an action that then failed with err, so the
+// failed action does not leave a feed behind that neither the rollback nor the journal knows of.
+// The delete is performed even if ctx is cancelled. err is returned, noting the feed if it could not
+// be deleted.
+func deleteCreatedFeed(
+	ctx context.Context, client MinifluxClient, feedID int64, retry RetryPolicy, err error,
+) error {
+	ctx = context.WithoutCancel(ctx)
 
-			feedID, err := findFeedIDByURL(action.FeedURL, feeds)
-			if err != nil {
-				return errors.Wrap(err, "finding feed id")
-			}
+	log.Warn(ctx, "deleting created feed, as the action failed", log.Metadata{
+		"feed_id": feedID,
+		"error":   err.Error(),
+	})
 
-			if err := client.DeleteFeed(feedID); err != nil {
-				return errors.Wrap(err, "deleting feed")
-			}
+	if deleteErr := withRetryDelete(ctx, retry, "deleting created feed", func() error {
+		return client.DeleteFeed(ctx, feedID)
+	}); deleteErr != nil {
+		return errors.Wrapf(err, "created feed %d could not be deleted (%s)", feedID, deleteErr)
+	}
 
-			feeds = removeFeedByID(feedID, feeds)
+	return err
+}
+
+// performAction performs a single action on the Miniflux instance, updating data to reflect it.
+func performAction( //nolint:cyclop,funlen
+	ctx context.Context,
+	client MinifluxClient,
+	data *remoteData,
+	action diff.Action,
+	retry RetryPolicy,
+) error {
+	switch action.Type {
+	case diff.CreateCategory:
+		log.Info(ctx, "creating category", log.Metadata{
+			"title": action.CategoryTitle,
+		})
+
+		category, err := createWithRetry(
+			ctx, retry, "creating category",
+			func() (*miniflux.Category, error) {
+				return client.CreateCategory(ctx, action.CategoryTitle)
+			},
+			func() (*miniflux.Category, bool, error) {
+				categories, err := client.Categories(ctx)
+				if err != nil {
+					return nil, false, err //nolint:wrapcheck
+				}
+
+				index := slices.IndexFunc(categories, func(c *miniflux.Category) bool {
+					return c.Title == action.CategoryTitle
+				})
+				if index == -1 {
+					return nil, false, nil
+				}
+
+				return categories[index], true, nil
+			},
+		)
+		if err != nil {
+			return errors.Wrap(err, "creating category")
+		}
+
+		data.addCategory(category)
+
+	case diff.CreateFeed:
+		log.Info(ctx, "creating feed", log.Metadata{
+			"category": action.CategoryTitle,
+			"url":      action.FeedURL,
+		})
+
+		categoryID, err := data.findCategoryIDByTitle(action.CategoryTitle)
+		if err != nil {
+			return errors.Wrap(err, "finding category id")
+		}
+
+		req := miniflux.FeedCreationRequest{
+			FeedURL:    action.FeedURL,
+			CategoryID: categoryID,
+		}
+
+		applyOptionsToCreationRequest(&req, action.FeedOptions)
+
+		feedID, err := createWithRetry(
+			ctx, retry, "creating feed",
+			func() (int64, error) {
+				return client.CreateFeed(ctx, &req)
+			},
+			func() (int64, bool, error) {
+				feeds, err := client.Feeds(ctx)
+				if err != nil {
+					return 0, false, err //nolint:wrapcheck
+				}
+
+				feedID, err := findFeedIDByURL(action.FeedURL, feeds)
+				if err != nil {
+					return 0, false, nil //nolint:nilerr
+				}
+
+				return feedID, true, nil
+			},
+		)
+		if err != nil {
+			return errors.Wrap(err, "creating feed")
+		}
+
+		feed, err := withRetry(ctx, retry, "fetching feed", func() (*miniflux.Feed, error) {
+			return client.Feed(ctx, feedID)
+		})
+		if err != nil {
+			return deleteCreatedFeed(ctx, client, feedID, retry, errors.Wrap(err, "fetching feed"))
+		}
+
+		data.addFeed(feed)
+
+	case diff.UpdateFeed:
+		log.Info(ctx, "updating feed", log.Metadata{
+			"category": action.CategoryTitle,
+			"url":      action.FeedURL,
+		})
+
+		feedID, err := data.findFeedIDByURL(action.FeedURL)
+		if err != nil {
+			return errors.Wrap(err, "finding feed id")
+		}
+
+		modReq := miniflux.FeedModificationRequest{}
+		applyOptionsToModificationRequest(&modReq, action.FeedOptions)
+
+		_, err = withRetry(ctx, retry, "updating feed", func() (*miniflux.Feed, error) {
+			return client.UpdateFeed(ctx, feedID, &modReq)
+		})
+		if err != nil {
+			return errors.Wrap(err, "updating feed")
+		}
+
+	case diff.DeleteCategory:
+		log.Info(ctx, "deleting category", log.Metadata{
+			"title": action.CategoryTitle,
+		})
+
+		categoryID, err := data.findCategoryIDByTitle(action.CategoryTitle)
+		if err != nil {
+			return errors.Wrap(err, "finding category id")
+		}
+
+		if err := withRetryDelete(ctx, retry, "deleting category", func() error {
+			return client.DeleteCategory(ctx, categoryID)
+		}); err != nil {
+			return errors.Wrap(err, "deleting category")
+		}
+
+		data.removeCategory(categoryID)
+
+	case diff.DeleteFeed:
+		log.Info(ctx, "deleting feed", log.Metadata{
+			"category": action.CategoryTitle,
+			"url":      action.FeedURL,
+		})
+
+		feedID, err := data.findFeedIDByURL(action.FeedURL)
+		if err != nil {
+			return errors.Wrap(err, "finding feed id")
+		}
 
-		default:
-			return errors.Errorf(`unknown action type: "%s"`, action.Type)
+		if err := withRetryDelete(ctx, retry, "deleting feed", func() error {
+			return client.DeleteFeed(ctx, feedID)
+		}); err != nil {
+			return errors.Wrap(err, "deleting feed")
 		}
+
+		data.removeFeed(feedID)
+
+	default:
+		return errors.Errorf(`unknown action type: "%s"`, action.Type)
 	}
 
 	return nil
 }
 
+// remoteData holds the feeds and categories known to exist on the Miniflux instance, which are
+// updated as actions are performed. It is safe for concurrent use.
+type remoteData struct {
+	mu         sync.Mutex
+	feeds      []*miniflux.Feed
+	categories []*miniflux.Category
+}
+
+func (r *remoteData) findCategoryIDByTitle(title string) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return findCategoryIDByTitle(title, r.categories)
+}
+
+func (r *remoteData) findFeedIDByURL(url string) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return findFeedIDByURL(url, r.feeds)
+}
+
+func (r *remoteData) findFeedByURL(url string) (*miniflux.Feed, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, feed := range r.feeds {
+		if feed.FeedURL == url {
+			return feed, nil
+		}
+	}
+
+	return nil, errors.Errorf(`feed not found: "%s"`, url)
+}
+
+func (r *remoteData) addCategory(category *miniflux.Category) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.categories = append(r.categories, category)
+}
+
+func (r *remoteData) addFeed(feed *miniflux.Feed) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.feeds = append(r.feeds, feed)
+}
+
+func (r *remoteData) removeCategory(id int64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.categories = removeCategoryByID(id, r.categories)
+}
+
+func (r *remoteData) removeFeed(id int64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.feeds = removeFeedByID(id, r.feeds)
+}
+
 func findCategoryIDByTitle(title string, categories []*miniflux.Category) (int64, error) {
 	for _, category := range categories {
 		if category.Title == title {
@@ -143,20 +437,24 @@ func findFeedIDByURL(url string, feeds []*miniflux.Feed) (int64, error) {
 	return 0, errors.Errorf(`feed not found: "%s"`, url)
 }
 
+// removeCategoryByID returns categories without the category with the given ID. A new slice is
+// returned, as appending in place would overwrite the elements of the caller's slice.
 func removeCategoryByID(id int64, categories []*miniflux.Category) []*miniflux.Category {
 	for i, category := range categories {
 		if category.ID == id {
-			return append(categories[:i], categories[i+1:]...)
+			return slices.Concat(categories[:i], categories[i+1:])
 		}
 	}
 
 	return categories
 }
 
+// removeFeedByID returns feeds without the feed with the given ID. A new slice is returned, as
+// appending in place would overwrite the elements of the caller's slice.
 func removeFeedByID(id int64, feeds []*miniflux.Feed) []*miniflux.Feed {
 	for i, feed := range feeds {
 		if feed.ID == id {
-			return append(feeds[:i], feeds[i+1:]...)
+			return slices.Concat(feeds[:i], feeds[i+1:])
 		}
 	}
 
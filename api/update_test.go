@@ -0,0 +1,509 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/revett/miniflux-sync/diff"
+	"github.com/revett/miniflux-sync/log"
+	"github.com/revett/miniflux-sync/minifluxtest"
+	"github.com/stretchr/testify/require"
+	miniflux "miniflux.app/v2/client"
+)
+
+// fakeClient is an in-memory MinifluxClient. Calls to a method named in errs fail with that error.
+type fakeClient struct {
+	mu         sync.Mutex
+	categories []*miniflux.Category
+	feeds      []*miniflux.Feed
+	nextID     int64
+	errs       map[string]error
+}
+
+func newFakeClient(categories []*miniflux.Category, feeds []*miniflux.Feed) *fakeClient {
+	return &fakeClient{
+		categories: categories,
+		feeds:      feeds,
+		nextID:     100, //nolint:mnd
+		errs:       map[string]error{},
+	}
+}
+
+func (f *fakeClient) Healthcheck(context.Context) error {
+	return f.errs["Healthcheck"]
+}
+
+func (f *fakeClient) Me(context.Context) (*miniflux.User, error) {
+	return &miniflux.User{ID: 1, Username: "fake"}, f.errs["Me"]
+}
+
+func (f *fakeClient) Version(context.Context) (*miniflux.VersionResponse, error) {
+	return &miniflux.VersionResponse{Version: "2.2.0"}, f.errs["Version"]
+}
+
+func (f *fakeClient) Categories(context.Context) (miniflux.Categories, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.categories, f.errs["Categories"]
+}
+
+func (f *fakeClient) CreateCategory(_ context.Context, title string) (*miniflux.Category, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.errs["CreateCategory"]; err != nil {
+		return nil, err
+	}
+
+	f.nextID++
+	category := &miniflux.Category{ID: f.nextID, Title: title}
+	f.categories = append(f.categories, category)
+
+	return category, nil
+}
+
+func (f *fakeClient) DeleteCategory(_ context.Context, categoryID int64) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.errs["DeleteCategory"]; err != nil {
+		return err
+	}
+
+	f.categories = removeCategoryByID(categoryID, f.categories)
+
+	return nil
+}
+
+func (f *fakeClient) Feeds(context.Context) (miniflux.Feeds, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.feeds, f.errs["Feeds"]
+}
+
+func (f *fakeClient) Feed(_ context.Context, feedID int64) (*miniflux.Feed, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.errs["Feed"]; err != nil {
+		return nil, err
+	}
+
+	for _, feed := range f.feeds {
+		if feed.ID == feedID {
+			return feed, nil
+		}
+	}
+
+	return nil, miniflux.ErrNotFound
+}
+
+func (f *fakeClient) CreateFeed(_ context.Context, req *miniflux.FeedCreationRequest) (int64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.errs["CreateFeed"]; err != nil {
+		return 0, err
+	}
+
+	var category *miniflux.Category
+	for _, c := range f.categories {
+		if c.ID == req.CategoryID {
+			category = c
+		}
+	}
+
+	if category == nil {
+		return 0, miniflux.ErrBadRequest
+	}
+
+	f.nextID++
+	f.feeds = append(f.feeds, &miniflux.Feed{
+		ID:           f.nextID,
+		FeedURL:      req.FeedURL,
+		Category:     category,
+		Crawler:      req.Crawler,
+		ScraperRules: req.ScraperRules,
+	})
+
+	return f.nextID, nil
+}
+
+func (f *fakeClient) UpdateFeed(
+	_ context.Context, feedID int64, req *miniflux.FeedModificationRequest,
+) (*miniflux.Feed, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.errs["UpdateFeed"]; err != nil {
+		return nil, err
+	}
+
+	for _, feed := range f.feeds {
+		if feed.ID != feedID {
+			continue
+		}
+
+		if req.Crawler != nil {
+			feed.Crawler = *req.Crawler
+		}
+		if req.ScraperRules != nil {
+			feed.ScraperRules = *req.ScraperRules
+		}
+
+		return feed, nil
+	}
+
+	return nil, miniflux.ErrNotFound
+}
+
+func (f *fakeClient) DeleteFeed(_ context.Context, feedID int64) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.errs["DeleteFeed"]; err != nil {
+		return err
+	}
+
+	f.feeds = removeFeedByID(feedID, f.feeds)
+
+	return nil
+}
+
+// remoteState returns the diff.State of the fake client.
+func (f *fakeClient) remoteState(t *testing.T) *diff.State {
+	t.Helper()
+
+	state, err := GenerateDiffState(f.feeds, f.categories)
+	require.NoError(t, err)
+
+	return state
+}
+
+func TestUpdate(t *testing.T) {
+	t.Parallel()
+
+	crawler := true
+	article := "article"
+
+	categories := []*miniflux.Category{{ID: 1, Title: "Tech"}, {ID: 2, Title: "Old"}}
+	feeds := []*miniflux.Feed{
+		{ID: 1, FeedURL: "https://tech.com/feed", Category: categories[0]},
+		{ID: 2, FeedURL: "https://old.com/feed", Category: categories[1]},
+		{ID: 3, FeedURL: "https://move.com/feed", Category: categories[1]},
+	}
+
+	local := &diff.State{
+		FeedURLsByCategoryTitle: map[string][]string{
+			"Tech":  {"https://tech.com/feed", "https://move.com/feed"},
+			"Music": {"https://music.com/feed"},
+		},
+		FeedsByCategoryTitle: map[string][]diff.Feed{
+			"Tech": {
+				{URL: "https://tech.com/feed", Options: diff.FeedOptions{ScraperRules: &article}},
+				{URL: "https://move.com/feed"},
+			},
+			"Music": {
+				{URL: "https://music.com/feed", Options: diff.FeedOptions{Crawler: &crawler}},
+			},
+		},
+	}
+
+	client := newFakeClient(
+		[]*miniflux.Category{categories[0], categories[1]},
+		[]*miniflux.Feed{feeds[0], feeds[1], feeds[2]},
+	)
+
+	actions, err := diff.CalculateDiff(local, client.remoteState(t))
+	require.NoError(t, err)
+
+	logger := log.New()
+	ctx := logger.WithContext(context.Background())
+
+	outcomes, err := Update(ctx, client, actions, feeds, categories, UpdateOptions{Concurrency: 2})
+	require.NoError(t, err)
+	require.Len(t, outcomes, len(actions))
+
+	for _, outcome := range outcomes {
+		require.Equal(t, ActionSucceeded, outcome.Status, outcome.Action.ID())
+	}
+
+	// Syncing again makes no changes.
+	actions, err = diff.CalculateDiff(local, client.remoteState(t))
+	require.NoError(t, err)
+	require.Empty(t, actions)
+
+	// The slices passed to Update are not modified.
+	require.Equal(t, []*miniflux.Feed{feeds[0], feeds[1], feeds[2]}, feeds)
+	require.Equal(t, []*miniflux.Category{categories[0], categories[1]}, categories)
+}
+
+func TestUpdate_Failure(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		action diff.Action
+		errs   map[string]error
+		err    string
+	}{
+		"UnknownCategory": {
+			action: diff.Action{
+				Type: diff.CreateFeed, CategoryTitle: "Music", FeedURL: "https://music.com/feed",
+			},
+			err: `finding category id: category not found: "Music"`,
+		},
+		"UnknownFeed": {
+			action: diff.Action{
+				Type: diff.DeleteFeed, CategoryTitle: "Tech", FeedURL: "https://other.com/feed",
+			},
+			err: `finding feed id: feed not found: "https://other.com/feed"`,
+		},
+		"ClientError": {
+			action: diff.Action{Type: diff.DeleteCategory, CategoryTitle: "Tech"},
+			errs:   map[string]error{"DeleteCategory": miniflux.ErrForbidden},
+			err:    "deleting category: miniflux: access forbidden",
+		},
+	}
+
+	for name, testCase := range tests {
+		tc := testCase
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			categories := []*miniflux.Category{{ID: 1, Title: "Tech"}}
+			feeds := []*miniflux.Feed{
+				{ID: 1, FeedURL: "https://tech.com/feed", Category: categories[0]},
+			}
+
+			client := newFakeClient(categories, feeds)
+			for method, err := range tc.errs {
+				client.errs[method] = err
+			}
+
+			logger := log.New()
+			ctx := logger.WithContext(context.Background())
+
+			outcomes, err := Update(
+				ctx, client, []diff.Action{tc.action}, feeds, categories, UpdateOptions{},
+			)
+			require.EqualError(t, err, tc.err)
+			require.Equal(t, ActionFailed, outcomes[0].Status)
+		})
+	}
+}
+
+func TestUpdate_Deadline(t *testing.T) {
+	t.Parallel()
+
+	server := minifluxtest.NewServer()
+	t.Cleanup(server.Close)
+
+	// Every request takes far longer than the run is allowed to.
+	server.SetLatency(10 * time.Second)
+
+	client := newHTTPClient(server.URL, http.DefaultTransport, minifluxtest.APIKey, "", "")
+
+	logger := log.New()
+	ctx, cancel := context.WithTimeout(logger.WithContext(context.Background()), 200*time.Millisecond)
+	defer cancel()
+
+	// Actions in flight are not cancelled along with the run, but still stop at its deadline.
+	start := time.Now()
+
+	outcomes, err := Update(
+		ctx, client, []diff.Action{{Type: diff.DeleteCategory, CategoryTitle: "Tech"}}, nil,
+		[]*miniflux.Category{{ID: 1, Title: "Tech"}}, UpdateOptions{},
+	)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	require.Equal(t, ActionFailed, outcomes[0].Status)
+	require.Less(t, time.Since(start), 5*time.Second)
+}
+
+func TestUpdate_RollbackOnFailure(t *testing.T) {
+	t.Parallel()
+
+	categories := []*miniflux.Category{{ID: 1, Title: "Tech"}}
+	feeds := []*miniflux.Feed{
+		{ID: 1, FeedURL: "https://tech.com/feed", Category: categories[0], ScraperRules: "article"},
+		{ID: 2, FeedURL: "https://other.com/feed", Category: categories[0]},
+	}
+
+	client := newFakeClient(categories, feeds)
+	before := client.remoteState(t)
+
+	// The update is performed last, and fails.
+	actions := []diff.Action{
+		{Type: diff.CreateCategory, CategoryTitle: "Music"},
+		{Type: diff.DeleteFeed, CategoryTitle: "Tech", FeedURL: "https://tech.com/feed"},
+		{
+			Type:          diff.UpdateFeed,
+			CategoryTitle: "Tech",
+			FeedURL:       "https://other.com/feed",
+			DependsOn: []string{
+				`["CreateCategory","Music",""]`, `["DeleteFeed","Tech","https://tech.com/feed"]`,
+			},
+		},
+	}
+
+	client.errs["UpdateFeed"] = errors.New("boom")
+
+	logger := log.New()
+	ctx := logger.WithContext(context.Background())
+
+	outcomes, err := Update(ctx, client, actions, feeds, categories, UpdateOptions{
+		RollbackOnFailure: true,
+	})
+	require.EqualError(t, err, "updating feed: boom")
+
+	// The deleted feed is recreated with its options, and the created category is deleted.
+	require.Equal(t, before.Fingerprint(), client.remoteState(t).Fingerprint())
+
+	for _, outcome := range outcomes {
+		if outcome.Action.Type == diff.UpdateFeed {
+			require.Equal(t, ActionFailed, outcome.Status)
+			continue
+		}
+
+		require.Equal(t, ActionRolledBack, outcome.Status, outcome.Action.ID())
+	}
+}
+
+func TestUpdate_CreatedFeedNotFetched(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		errs     map[string]error
+		err      string
+		feedLeft bool
+	}{
+		"CreatedFeedDeleted": {
+			errs: map[string]error{"Feed": miniflux.ErrForbidden},
+			err:  "fetching feed: miniflux: access forbidden",
+		},
+		"CreatedFeedNotDeleted": {
+			errs:     map[string]error{"Feed": miniflux.ErrForbidden, "DeleteFeed": errors.New("boom")},
+			err:      "created feed 101 could not be deleted (boom): fetching feed: miniflux: access forbidden",
+			feedLeft: true,
+		},
+	}
+
+	for name, testCase := range tests {
+		tc := testCase
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			categories := []*miniflux.Category{{ID: 1, Title: "Tech"}}
+			feeds := []*miniflux.Feed{
+				{ID: 1, FeedURL: "https://tech.com/feed", Category: categories[0]},
+			}
+
+			client := newFakeClient(categories, feeds)
+			for method, err := range tc.errs {
+				client.errs[method] = err
+			}
+
+			logger := log.New()
+			ctx := logger.WithContext(context.Background())
+
+			// The feed is created, but fetching it fails, so the action fails.
+			outcomes, err := Update(ctx, client, []diff.Action{
+				{Type: diff.CreateFeed, CategoryTitle: "Tech", FeedURL: "https://new.com/feed"},
+			}, feeds, categories, UpdateOptions{RollbackOnFailure: true})
+			require.EqualError(t, err, tc.err)
+			require.Equal(t, ActionFailed, outcomes[0].Status)
+
+			// The created feed is not left behind unrecorded.
+			require.Equal(t, tc.feedLeft, slices.ContainsFunc(client.feeds, func(feed *miniflux.Feed) bool {
+				return feed.FeedURL == "https://new.com/feed"
+			}))
+		})
+	}
+}
+
+func TestRemoveFeedByID(t *testing.T) {
+	t.Parallel()
+
+	feeds := []*miniflux.Feed{{ID: 1}, {ID: 2}, {ID: 3}}
+
+	tests := map[string]struct {
+		id       int64
+		expected []*miniflux.Feed
+	}{
+		"First":   {id: 1, expected: []*miniflux.Feed{feeds[1], feeds[2]}},
+		"Middle":  {id: 2, expected: []*miniflux.Feed{feeds[0], feeds[2]}},
+		"Last":    {id: 3, expected: []*miniflux.Feed{feeds[0], feeds[1]}},
+		"Missing": {id: 4, expected: feeds},
+	}
+
+	for name, testCase := range tests {
+		tc := testCase
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			input := []*miniflux.Feed{feeds[0], feeds[1], feeds[2]}
+
+			require.Equal(t, tc.expected, removeFeedByID(tc.id, input))
+			require.Equal(t, feeds, input, "input slice was modified")
+		})
+	}
+}
+
+func TestRemoveCategoryByID(t *testing.T) {
+	t.Parallel()
+
+	categories := []*miniflux.Category{{ID: 1}, {ID: 2}, {ID: 3}}
+
+	tests := map[string]struct {
+		id       int64
+		expected []*miniflux.Category
+	}{
+		"First":   {id: 1, expected: []*miniflux.Category{categories[1], categories[2]}},
+		"Middle":  {id: 2, expected: []*miniflux.Category{categories[0], categories[2]}},
+		"Last":    {id: 3, expected: []*miniflux.Category{categories[0], categories[1]}},
+		"Missing": {id: 4, expected: categories},
+	}
+
+	for name, testCase := range tests {
+		tc := testCase
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			input := []*miniflux.Category{categories[0], categories[1], categories[2]}
+
+			require.Equal(t, tc.expected, removeCategoryByID(tc.id, input))
+			require.Equal(t, categories, input, "input slice was modified")
+		})
+	}
+}
+
+func TestFetchData(t *testing.T) {
+	t.Parallel()
+
+	categories := []*miniflux.Category{{ID: 1, Title: "Tech"}}
+	feeds := []*miniflux.Feed{{ID: 1, FeedURL: "https://tech.com/feed", Category: categories[0]}}
+
+	logger := log.New()
+	ctx := logger.WithContext(context.Background())
+
+	gotFeeds, gotCategories, err := FetchData(ctx, newFakeClient(categories, feeds), RetryPolicy{})
+	require.NoError(t, err)
+	require.Equal(t, feeds, gotFeeds)
+	require.Equal(t, categories, gotCategories)
+
+	client := newFakeClient(categories, feeds)
+	client.errs["Categories"] = miniflux.ErrNotAuthorized
+
+	_, _, err = FetchData(ctx, client, RetryPolicy{})
+	require.EqualError(t, err, "fetching categories: miniflux: unauthorized (bad credentials)")
+}
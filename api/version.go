@@ -0,0 +1,135 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/revett/miniflux-sync/log"
+	miniflux "miniflux.app/v2/client"
+)
+
+// ServerVersion is the version of a Miniflux server. The zero value is an unknown version.
+type ServerVersion struct {
+	Major int
+	Minor int
+	Patch int
+}
+
+// Known returns true if the version was detected.
+func (v ServerVersion) Known() bool {
+	return v != ServerVersion{}
+}
+
+// AtLeast returns true if the version is the same as, or newer than, other.
+func (v ServerVersion) AtLeast(other ServerVersion) bool {
+	if v.Major != other.Major {
+		return v.Major > other.Major
+	}
+
+	if v.Minor != other.Minor {
+		return v.Minor > other.Minor
+	}
+
+	return v.Patch >= other.Patch
+}
+
+func (v ServerVersion) String() string {
+	if !v.Known() {
+		return "unknown"
+	}
+
+	return fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+}
+
+// parseServerVersion parses a version such as "2.2.0" or "v2.0.49", ignoring any suffix of the
+// patch version, e.g. "2.2.0-rc1".
+func parseServerVersion(s string) (ServerVersion, error) {
+	const versionParts = 3
+
+	parts := strings.SplitN(strings.TrimPrefix(strings.TrimSpace(s), "v"), ".", versionParts)
+	if len(parts) != versionParts {
+		return ServerVersion{}, errors.Errorf(`invalid miniflux version "%s"`, s)
+	}
+
+	parts[2], _, _ = strings.Cut(parts[2], "-")
+
+	numbers := make([]int, len(parts))
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return ServerVersion{}, errors.Errorf(`invalid miniflux version "%s"`, s)
+		}
+
+		numbers[i] = n
+	}
+
+	return ServerVersion{Major: numbers[0], Minor: numbers[1], Patch: numbers[2]}, nil
+}
+
+// versionedClient is a MinifluxClient along with the version of its server, as detected when
+// connecting.
+type versionedClient struct {
+	MinifluxClient
+	version ServerVersion
+}
+
+// DetectedVersion returns the server version detected when the client was created by Client. It is
+// unknown for other clients, or when the version could not be detected.
+func DetectedVersion(client MinifluxClient) ServerVersion {
+	if c, ok := client.(*versionedClient); ok {
+		return c.version
+	}
+
+	return ServerVersion{}
+}
+
+// legacyVersionClient is a MinifluxClient which can fetch the version of servers older than 2.0.49.
+type legacyVersionClient interface {
+	LegacyVersion(ctx context.Context) (string, error)
+}
+
+// detectVersion fetches the version of the server, falling back to the "/version" endpoint for
+// servers older than 2.0.49, which do not have "/v1/version", if the client supports it. The
+// version is unknown if neither works, as it is only used to check feed options.
+func detectVersion(ctx context.Context, client MinifluxClient, retry RetryPolicy) ServerVersion {
+	raw := ""
+
+	resp, err := withRetry(
+		ctx, retry, "fetching miniflux version", func() (*miniflux.VersionResponse, error) {
+			return client.Version(ctx)
+		},
+	)
+	if err == nil {
+		raw = resp.Version
+	}
+
+	if legacy, ok := client.(legacyVersionClient); ok && errors.Is(err, miniflux.ErrNotFound) {
+		raw, err = legacy.LegacyVersion(ctx)
+	}
+
+	if err != nil {
+		log.Warn(ctx, "could not detect miniflux version, feed options will not be checked", log.Metadata{
+			"error": err.Error(),
+		})
+
+		return ServerVersion{}
+	}
+
+	version, err := parseServerVersion(raw)
+	if err != nil {
+		log.Warn(ctx, "could not detect miniflux version, feed options will not be checked", log.Metadata{
+			"error": err.Error(),
+		})
+
+		return ServerVersion{}
+	}
+
+	log.Info(ctx, "detected miniflux version", log.Metadata{
+		"version": version.String(),
+	})
+
+	return version
+}
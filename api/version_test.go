@@ -0,0 +1,84 @@
+package api
+
+import (
+	"context"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/revett/miniflux-sync/log"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseServerVersion(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		input    string
+		expected ServerVersion
+		err      bool
+	}{
+		"Release": {
+			input:    "2.2.0",
+			expected: ServerVersion{2, 2, 0},
+		},
+		"Prefixed": {
+			input:    "v2.0.49\n",
+			expected: ServerVersion{2, 0, 49},
+		},
+		"Suffixed": {
+			input:    "2.1.1-rc1",
+			expected: ServerVersion{2, 1, 1},
+		},
+		"Development": {
+			input: "Development Version",
+			err:   true,
+		},
+		"MissingPatch": {
+			input: "2.1",
+			err:   true,
+		},
+	}
+
+	for name, testCase := range tests {
+		tc := testCase
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			version, err := parseServerVersion(tc.input)
+			if tc.err {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, version)
+		})
+	}
+}
+
+func TestServerVersionAtLeast(t *testing.T) {
+	t.Parallel()
+
+	version := ServerVersion{2, 1, 1}
+
+	require.True(t, version.AtLeast(ServerVersion{2, 1, 1}))
+	require.True(t, version.AtLeast(ServerVersion{2, 0, 49}))
+	require.True(t, version.AtLeast(ServerVersion{1, 9, 9}))
+	require.False(t, version.AtLeast(ServerVersion{2, 1, 2}))
+	require.False(t, version.AtLeast(ServerVersion{2, 2, 0}))
+	require.False(t, version.AtLeast(ServerVersion{3, 0, 0}))
+}
+
+func TestDetectVersion(t *testing.T) {
+	t.Parallel()
+
+	logger := log.New()
+	ctx := logger.WithContext(context.Background())
+
+	client := newFakeClient(nil, nil)
+	require.Equal(t, ServerVersion{2, 2, 0}, detectVersion(ctx, client, RetryPolicy{}))
+
+	client.errs["Version"] = errors.New("unavailable")
+	require.False(t, detectVersion(ctx, client, RetryPolicy{}).Known())
+}
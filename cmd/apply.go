@@ -0,0 +1,56 @@
+package cmd
+
+import (
+	"context"
+	"os"
+
+	"github.com/pkg/errors"
+	"github.com/revett/miniflux-sync/api"
+	"github.com/revett/miniflux-sync/config"
+	"github.com/revett/miniflux-sync/log"
+	"github.com/revett/miniflux-sync/plan"
+)
+
+func apply(
+	ctx context.Context,
+	p *plan.Plan,
+	flags *config.ApplyFlags,
+	client api.MinifluxClient,
+	retry api.RetryPolicy,
+) error {
+	feeds, categories, remoteState, err := fetchRemoteState(ctx, client, retry)
+	if err != nil {
+		return err
+	}
+
+	log.Info(ctx, "checking remote state against plan")
+	if err := p.Verify(remoteState); err != nil {
+		return errors.Wrap(err, "verifying plan, re-run plan to create a new one")
+	}
+
+	if len(p.Actions) == 0 {
+		log.Info(ctx, "no actions to perform")
+		return nil
+	}
+
+	logActions(ctx, p.Actions)
+
+	outcomes, err := api.Update(ctx, client, p.Actions, feeds, categories, api.UpdateOptions{
+		Concurrency:       flags.Concurrency,
+		KeepGoing:         flags.KeepGoing,
+		Retry:             retry,
+		RollbackOnFailure: flags.RollbackOnFailure,
+	})
+
+	if (flags.KeepGoing || flags.RollbackOnFailure) && outcomes != nil {
+		if err := writeOutcomeSummary(os.Stdout, outcomes); err != nil {
+			return err
+		}
+	}
+
+	if err != nil {
+		return errors.Wrap(err, "performing actions")
+	}
+
+	return nil
+}
@@ -0,0 +1,75 @@
+package cmd
+
+import (
+	"context"
+
+	"github.com/revett/miniflux-sync/api"
+	"github.com/revett/miniflux-sync/config"
+	"github.com/revett/miniflux-sync/diff"
+	"github.com/revett/miniflux-sync/log"
+)
+
+// driftExitCode is the exit code used by the check command when Miniflux differs from the local
+// YAML file. Errors exit with 1.
+const driftExitCode = 2
+
+// check reports any drift between the local YAML file and Miniflux, returning true if drift was
+// detected.
+func check(
+	ctx context.Context, flags *config.CheckFlags, client api.MinifluxClient, retry api.RetryPolicy,
+) (bool, error) {
+	result, err := calculatePlan(ctx, flags.Path, flags.UnsupportedOptions, client, retry)
+	if err != nil {
+		return false, err
+	}
+
+	drift := diff.NewDrift(result.actions)
+
+	for _, title := range drift.RemoteOnlyCategories {
+		log.Warn(ctx, "remote only category", log.Metadata{
+			"category_title": title,
+		})
+	}
+	for _, action := range drift.RemoteOnlyFeeds {
+		log.Warn(ctx, "remote only feed", log.Metadata{
+			"category_title": action.CategoryTitle,
+			"feed_url":       action.FeedURL,
+		})
+	}
+	for _, title := range drift.LocalOnlyCategories {
+		log.Warn(ctx, "local only category", log.Metadata{
+			"category_title": title,
+		})
+	}
+	for _, action := range drift.LocalOnlyFeeds {
+		log.Warn(ctx, "local only feed", log.Metadata{
+			"category_title": action.CategoryTitle,
+			"feed_url":       action.FeedURL,
+		})
+	}
+	for _, action := range drift.OptionMismatches {
+		log.Warn(ctx, "feed option mismatch", log.Metadata{
+			"category_title": action.CategoryTitle,
+			"feed_url":       action.FeedURL,
+		})
+
+		for _, change := range action.OptionChanges() {
+			log.Warn(ctx, "  "+change.String())
+		}
+	}
+
+	if !drift.Detected() {
+		log.Info(ctx, "no drift detected")
+		return false, nil
+	}
+
+	log.Warn(ctx, "drift detected", log.Metadata{
+		"remote_only_categories": len(drift.RemoteOnlyCategories),
+		"remote_only_feeds":      len(drift.RemoteOnlyFeeds),
+		"local_only_categories":  len(drift.LocalOnlyCategories),
+		"local_only_feeds":       len(drift.LocalOnlyFeeds),
+		"option_mismatches":      len(drift.OptionMismatches),
+	})
+
+	return true, nil
+}
@@ -6,12 +6,17 @@ import (
 	"github.com/pkg/errors"
 	"github.com/revett/miniflux-sync/api"
 	"github.com/revett/miniflux-sync/config"
+	"github.com/revett/miniflux-sync/parse"
+	"github.com/revett/miniflux-sync/plan"
 	"github.com/urfave/cli/v2"
 )
 
 // Commands returns the commands for the CLI.
 func Commands(ctx context.Context, cfg *config.GlobalFlags) []*cli.Command {
+	applyFlags := &config.ApplyFlags{}
+	checkFlags := &config.CheckFlags{}
 	dumpFlags := &config.DumpFlags{}
+	planFlags := &config.PlanFlags{}
 	syncFlags := &config.SyncFlags{}
 
 	return []*cli.Command{
@@ -21,13 +26,113 @@ func Commands(ctx context.Context, cfg *config.GlobalFlags) []*cli.Command {
 			Usage:   "Update Miniflux using a local YAML file.",
 			Flags:   syncFlags.Flags(ctx),
 			Action: func(*cli.Context) error {
-				client, err := api.Client(ctx, cfg)
+				ctx := outputContext(ctx, syncFlags.Output)
+
+				// The timeout applies to each sync when watching, rather than to the whole run.
+				if syncFlags.Watch {
+					return watch(ctx, cfg, syncFlags)
+				}
+
+				ctx, cancel := withTimeout(ctx, cfg)
+				defer cancel()
+
+				if len(syncFlags.Targets) > 0 {
+					return syncTargets(ctx, cfg, syncFlags)
+				}
+
+				if err := resolveConfig(ctx, cfg, syncFlags); err != nil {
+					return err
+				}
+
+				return runSync(ctx, cfg, syncFlags)
+			},
+		},
+		{
+			Name:    "plan",
+			Aliases: []string{"p"},
+			Usage:   "Show the changes sync would make, optionally saving them for apply.",
+			Flags:   planFlags.Flags(ctx),
+			Action: func(*cli.Context) error {
+				ctx, cancel := withTimeout(outputContext(ctx, planFlags.Output), cfg)
+				defer cancel()
+
+				if err := resolveConfig(ctx, cfg, planFlags); err != nil {
+					return err
+				}
+
+				client, err := newClient(ctx, cfg, planFlags.Path)
+				if err != nil {
+					return err
+				}
+
+				if err := planCmd(ctx, planFlags, client, api.NewRetryPolicy(cfg)); err != nil {
+					return errors.Wrap(err, "running plan command")
+				}
+
+				return nil
+			},
+		},
+		{
+			Name:      "apply",
+			Aliases:   []string{"a"},
+			Usage:     "Apply a saved plan, if the remote Miniflux state has not changed since.",
+			ArgsUsage: "<plan.json>",
+			Flags:     applyFlags.Flags(),
+			Action: func(c *cli.Context) error {
+				if err := applyFlags.Parse(ctx, c); err != nil {
+					return errors.Wrap(err, "parsing arguments")
+				}
+
+				ctx, cancel := withTimeout(ctx, cfg)
+				defer cancel()
+
+				if err := resolveConfig(ctx, cfg, nil); err != nil {
+					return err
+				}
+
+				p, err := plan.Read(ctx, applyFlags.PlanPath)
+				if err != nil {
+					return errors.Wrap(err, "loading plan")
+				}
+
+				client, err := api.Client(ctx, cfg, p.Identity)
 				if err != nil {
 					return errors.Wrap(err, "creating miniflux client")
 				}
 
-				if err := sync(ctx, syncFlags, client); err != nil {
-					return errors.Wrap(err, "running sync command")
+				if err := apply(ctx, p, applyFlags, client, api.NewRetryPolicy(cfg)); err != nil {
+					return errors.Wrap(err, "running apply command")
+				}
+
+				return nil
+			},
+		},
+		{
+			Name:    "check",
+			Aliases: []string{"c"},
+			Usage:   "Check for drift between Miniflux and a local YAML file. Exits 2 on drift, 1 on error.",
+			Flags:   checkFlags.Flags(ctx),
+			Action: func(*cli.Context) error {
+				ctx, cancel := withTimeout(ctx, cfg)
+				defer cancel()
+
+				if err := resolveConfig(ctx, cfg, checkFlags); err != nil {
+					return err
+				}
+
+				client, err := newClient(ctx, cfg, checkFlags.Path)
+				if err != nil {
+					return err
+				}
+
+				drifted, err := check(ctx, checkFlags, client, api.NewRetryPolicy(cfg))
+				if err != nil {
+					return errors.Wrap(err, "running check command")
+				}
+
+				if drifted {
+					// Returned unwrapped, as the cli only uses exit codes from errors it can assert.
+					return cli.Exit("", driftExitCode)
 				}
 
 				return nil
@@ -39,12 +144,19 @@ func Commands(ctx context.Context, cfg *config.GlobalFlags) []*cli.Command {
 			Usage:   "Dump the current remote Miniflux state to your machine.",
 			Flags:   dumpFlags.Flags(ctx),
 			Action: func(*cli.Context) error {
-				client, err := api.Client(ctx, cfg)
+				ctx, cancel := withTimeout(ctx, cfg)
+				defer cancel()
+
+				if err := resolveConfig(ctx, cfg, dumpFlags); err != nil {
+					return err
+				}
+
+				client, err := api.Client(ctx, cfg, nil)
 				if err != nil {
 					return errors.Wrap(err, "creating miniflux client")
 				}
 
-				if err := dump(ctx, dumpFlags, client); err != nil {
+				if err := dump(ctx, dumpFlags, client, api.NewRetryPolicy(cfg)); err != nil {
 					return errors.Wrap(err, "running dump command")
 				}
 
@@ -53,3 +165,67 @@ func Commands(ctx context.Context, cfg *config.GlobalFlags) []*cli.Command {
 		},
 	}
 }
+
+// commandFlags are the flags of a command with settings which can be set by the profile.
+type commandFlags interface {
+	Resolve(ctx context.Context, cfg *config.GlobalFlags) error
+}
+
+// resolveConfig fills the settings not set by flags or env vars from the selected profile, and
+// checks that the required settings are set. flags is nil for commands without such settings.
+func resolveConfig(ctx context.Context, cfg *config.GlobalFlags, flags commandFlags) error {
+	if err := cfg.Resolve(ctx); err != nil {
+		return errors.Wrap(err, "resolving configuration")
+	}
+
+	if flags == nil {
+		return nil
+	}
+
+	if err := flags.Resolve(ctx, cfg); err != nil {
+		return errors.Wrap(err, "resolving configuration")
+	}
+
+	return nil
+}
+
+// newClient creates the Miniflux client for a command which reads the YAML file at path, checking
+// that the client is for the account the file declares, if any.
+func newClient(
+	ctx context.Context, cfg *config.GlobalFlags, path string,
+) (api.MinifluxClient, error) {
+	identity, err := parse.Identity(ctx, path)
+	if err != nil {
+		return nil, errors.Wrap(err, "reading identity from data file")
+	}
+
+	client, err := api.Client(ctx, cfg, identity)
+	if err != nil {
+		return nil, errors.Wrap(err, "creating miniflux client")
+	}
+
+	return client, nil
+}
+
+// runSync connects to the Miniflux instance and syncs the data file to it.
+func runSync(ctx context.Context, cfg *config.GlobalFlags, flags *config.SyncFlags) error {
+	client, err := newClient(ctx, cfg, flags.Path)
+	if err != nil {
+		return err
+	}
+
+	if err := sync(ctx, flags, client, api.NewRetryPolicy(cfg)); err != nil {
+		return errors.Wrap(err, "running sync command")
+	}
+
+	return nil
+}
+
+// withTimeout returns a context which is cancelled once the timeout for the whole run has passed.
+func withTimeout(ctx context.Context, cfg *config.GlobalFlags) (context.Context, context.CancelFunc) {
+	if cfg.Timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+
+	return context.WithTimeout(ctx, cfg.Timeout)
+}
@@ -0,0 +1,141 @@
+package cmd
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/revett/miniflux-sync/api"
+	"github.com/revett/miniflux-sync/config"
+	"github.com/revett/miniflux-sync/log"
+	"github.com/revett/miniflux-sync/minifluxtest"
+	"github.com/stretchr/testify/require"
+	"github.com/urfave/cli/v2"
+)
+
+func TestNewClient_Identity(t *testing.T) {
+	t.Parallel()
+
+	server := minifluxtest.NewServer()
+	t.Cleanup(server.Close)
+
+	tests := map[string]struct {
+		identity string
+		err      error
+	}{
+		"NoIdentity": {},
+		"Match": {
+			identity: "_miniflux:\n  username: " + minifluxtest.Username + "\n  endpoint: " + server.URL + "\n",
+		},
+		"Mismatch": {
+			identity: "_miniflux:\n  username: someone-else\n",
+			err:      api.ErrIdentityMismatch,
+		},
+	}
+
+	for name, testCase := range tests {
+		tc := testCase
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			logger := log.New()
+			ctx := logger.WithContext(context.Background())
+
+			path := filepath.Join(t.TempDir(), "feeds.yml")
+			require.NoError(t, os.WriteFile(path, []byte(tc.identity+testFeedsYAML), 0o600))
+
+			cfg := &config.GlobalFlags{Endpoint: server.URL, APIKey: minifluxtest.APIKey, Burst: 1}
+
+			_, err := newClient(ctx, cfg, path)
+			if tc.err != nil {
+				require.ErrorIs(t, err, tc.err)
+				return
+			}
+
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestCalculatePlan_UnsupportedOptions(t *testing.T) {
+	t.Parallel()
+
+	server := minifluxtest.NewServer()
+	t.Cleanup(server.Close)
+
+	// Servers older than 2.0.49 only report their version at "/version".
+	server.SetVersion("2.0.48")
+	server.InjectFault(minifluxtest.Fault{
+		Method:     http.MethodGet,
+		Path:       "/v1/version",
+		StatusCode: http.StatusNotFound,
+	})
+
+	logger := log.New()
+	ctx := logger.WithContext(context.Background())
+
+	path := filepath.Join(t.TempDir(), "feeds.yml")
+	require.NoError(t, os.WriteFile(path, []byte(
+		"news:\n  - url: https://example.com/news.xml\n    crawler: true\n    disable_http2: true\n",
+	), 0o600))
+
+	cfg := &config.GlobalFlags{Endpoint: server.URL, APIKey: minifluxtest.APIKey, Burst: 1}
+
+	client, err := newClient(ctx, cfg, path)
+	require.NoError(t, err)
+	require.Equal(t, api.ServerVersion{Major: 2, Minor: 0, Patch: 48}, api.DetectedVersion(client))
+
+	_, err = calculatePlan(ctx, path, config.UnsupportedOptionsFail, client, api.RetryPolicy{})
+	require.ErrorContains(
+		t, err, `miniflux 2.0.48 does not support feed options: "disable_http2" for https://example.com/news.xml (since 2.1.1)`,
+	)
+
+	result, err := calculatePlan(ctx, path, config.UnsupportedOptionsWarn, client, api.RetryPolicy{})
+	require.NoError(t, err)
+	require.Len(t, result.actions, 2)
+
+	for _, action := range result.actions {
+		if action.FeedURL != "" {
+			require.Nil(t, action.FeedOptions.DisableHTTP2)
+			require.NotNil(t, action.FeedOptions.Crawler)
+		}
+	}
+}
+
+func TestCommands_Timeout(t *testing.T) {
+	t.Parallel()
+
+	server := minifluxtest.NewServer()
+	t.Cleanup(server.Close)
+
+	// Every request takes far longer than the run is allowed to.
+	server.SetLatency(10 * time.Second)
+
+	logger := log.New()
+	ctx := logger.WithContext(context.Background())
+
+	cfg := config.New("test")
+	app := &cli.App{
+		Name:     "miniflux-sync",
+		Flags:    cfg.Flags(),
+		Commands: Commands(ctx, cfg),
+	}
+
+	start := time.Now()
+
+	err := app.Run([]string{
+		"miniflux-sync",
+		"--endpoint", server.URL,
+		"--api-key", minifluxtest.APIKey,
+		"--timeout", "200ms",
+		"sync",
+		"--path", writeFeedsFile(t),
+	})
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	require.Less(t, time.Since(start), 5*time.Second)
+	require.Empty(t, server.Feeds())
+}
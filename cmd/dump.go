@@ -12,13 +12,14 @@ import (
 	"github.com/revett/miniflux-sync/diff"
 	"github.com/revett/miniflux-sync/log"
 	"gopkg.in/yaml.v2"
-	miniflux "miniflux.app/v2/client"
 )
 
-func dump(ctx context.Context, flags *config.DumpFlags, client *miniflux.Client) error {
+func dump(
+	ctx context.Context, flags *config.DumpFlags, client api.MinifluxClient, retry api.RetryPolicy,
+) error {
 	log.Info(ctx, "exporting data from miniflux")
 
-	feeds, categories, err := api.FetchData(ctx, client)
+	feeds, categories, err := api.FetchData(ctx, client, retry)
 	if err != nil {
 		return errors.Wrap(err, "fetching data")
 	}
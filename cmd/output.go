@@ -0,0 +1,87 @@
+package cmd
+
+import (
+	"context"
+	"io"
+	"os"
+
+	"github.com/pkg/errors"
+	"github.com/revett/miniflux-sync/config"
+	"github.com/revett/miniflux-sync/log"
+	"github.com/revett/miniflux-sync/report"
+)
+
+// outputContext returns a context whose logger writes to stderr when the plan is rendered as a
+// document on stdout, so that log lines never end up in the document.
+func outputContext(ctx context.Context, output string) context.Context {
+	if output == config.OutputText {
+		return ctx
+	}
+
+	logger := log.NewWithWriter(os.Stderr)
+	return logger.WithContext(ctx)
+}
+
+// outputWriter returns the writer for tables and other human-readable output, which is stderr when
+// the plan is rendered as a document on stdout.
+func outputWriter(output string) io.Writer {
+	if output == config.OutputText {
+		return os.Stdout
+	}
+
+	return os.Stderr
+}
+
+// renderPlan renders the calculated actions in the requested output format.
+func renderPlan(ctx context.Context, output string, result *planResult) error {
+	switch output {
+	case config.OutputJSON:
+		r := report.New(result.localState, result.remoteState, result.actions)
+		if err := r.WriteJSON(os.Stdout); err != nil {
+			return errors.Wrap(err, "writing json report")
+		}
+
+	case config.OutputMarkdown:
+		r := report.New(result.localState, result.remoteState, result.actions)
+		if err := r.WriteMarkdown(os.Stdout); err != nil {
+			return errors.Wrap(err, "writing markdown report")
+		}
+
+		if err := appendStepSummary(ctx, r); err != nil {
+			return errors.Wrap(err, "appending github step summary")
+		}
+
+	default:
+		if len(result.actions) == 0 {
+			log.Info(ctx, "no actions to perform")
+			return nil
+		}
+
+		logActions(ctx, result.actions)
+	}
+
+	return nil
+}
+
+// appendStepSummary appends the Markdown report to the GitHub Actions job summary, if running
+// within GitHub Actions.
+func appendStepSummary(ctx context.Context, r *report.Report) error {
+	path := os.Getenv("GITHUB_STEP_SUMMARY")
+	if path == "" {
+		return nil
+	}
+
+	log.Info(ctx, "appending plan to github step summary")
+
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600) //nolint:gosec,mnd
+	if err != nil {
+		return errors.Wrap(err, "opening github step summary file")
+	}
+	defer file.Close()
+
+	if err := r.WriteMarkdown(file); err != nil {
+		return errors.Wrap(err, "writing markdown report")
+	}
+
+	return nil
+}
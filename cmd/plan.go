@@ -0,0 +1,42 @@
+package cmd
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	"github.com/revett/miniflux-sync/api"
+	"github.com/revett/miniflux-sync/config"
+	"github.com/revett/miniflux-sync/parse"
+	"github.com/revett/miniflux-sync/plan"
+)
+
+func planCmd(
+	ctx context.Context, flags *config.PlanFlags, client api.MinifluxClient, retry api.RetryPolicy,
+) error {
+	result, err := calculatePlan(ctx, flags.Path, flags.UnsupportedOptions, client, retry)
+	if err != nil {
+		return err
+	}
+
+	if err := renderPlan(ctx, flags.Output, result); err != nil {
+		return errors.Wrap(err, "rendering plan")
+	}
+
+	if flags.Out == "" {
+		return nil
+	}
+
+	identity, err := parse.Identity(ctx, flags.Path)
+	if err != nil {
+		return errors.Wrap(err, "reading identity from data file")
+	}
+
+	p := plan.New(result.actions, result.remoteState)
+	p.Identity = identity
+
+	if err := plan.Write(ctx, flags.Out, p); err != nil {
+		return errors.Wrap(err, "saving plan")
+	}
+
+	return nil
+}
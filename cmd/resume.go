@@ -0,0 +1,93 @@
+package cmd
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	"github.com/revett/miniflux-sync/api"
+	"github.com/revett/miniflux-sync/config"
+	"github.com/revett/miniflux-sync/diff"
+	"github.com/revett/miniflux-sync/journal"
+	"github.com/revett/miniflux-sync/log"
+)
+
+// resume finishes an interrupted sync using its journal. Each action in the journal is reconciled
+// with the current remote state, so actions which were applied, including those interrupted before
+// their completion was recorded, are not performed again. The remote state is trusted over the
+// journal, as it may have changed since the sync was interrupted.
+func resume(
+	ctx context.Context, flags *config.SyncFlags, client api.MinifluxClient, retry api.RetryPolicy,
+) error {
+	if flags.Output != config.OutputText {
+		return errors.Errorf(`resuming a sync only supports "%s" output`, config.OutputText)
+	}
+
+	entries, err := journal.Read(ctx, flags.JournalPath())
+	if err != nil {
+		return errors.Wrap(err, "loading journal")
+	}
+
+	feeds, categories, remoteState, err := fetchRemoteState(ctx, client, retry)
+	if err != nil {
+		return err
+	}
+
+	statusByID := make(map[string]journal.Status, len(entries))
+	actions := make([]diff.Action, 0, len(entries))
+
+	for _, entry := range entries {
+		statusByID[entry.Action.ID()] = entry.Status
+		actions = append(actions, entry.Action)
+	}
+
+	remaining := diff.Remaining(actions, func(action diff.Action) bool {
+		applied := remoteState.IsApplied(action)
+
+		switch statusByID[action.ID()] {
+		case journal.StatusStarted:
+			log.Info(ctx, "reconciled interrupted action", log.Metadata{
+				"action":  action.ID(),
+				"applied": applied,
+			})
+
+		case journal.StatusCompleted:
+			if !applied {
+				log.Warn(ctx, "completed action is no longer applied, performing it again", log.Metadata{
+					"action": action.ID(),
+				})
+			}
+
+		case journal.StatusPending, journal.StatusFailed:
+		}
+
+		return applied
+	})
+
+	log.Info(ctx, "resuming sync", log.Metadata{
+		"done":      len(actions) - len(remaining),
+		"remaining": len(remaining),
+	})
+
+	if len(remaining) > 0 {
+		logActions(ctx, remaining)
+	}
+
+	if flags.DryRun {
+		log.Info(ctx, "dry run complete")
+		return nil
+	}
+
+	j, err := journal.Open(flags.JournalPath())
+	if err != nil {
+		return errors.Wrap(err, "opening journal")
+	}
+
+	result := &planResult{
+		actions:     remaining,
+		categories:  categories,
+		feeds:       feeds,
+		remoteState: remoteState,
+	}
+
+	return performActions(ctx, flags, client, result, j, retry)
+}
@@ -0,0 +1,51 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	"github.com/pkg/errors"
+	"github.com/revett/miniflux-sync/api"
+)
+
+// writeOutcomeSummary writes a table of the outcome of every action, followed by the number of
+// actions with each status.
+func writeOutcomeSummary(w io.Writer, outcomes []api.ActionOutcome) error {
+	table := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0) //nolint:mnd
+
+	fmt.Fprintln(table, "STATUS\tACTION\tCATEGORY\tFEED\tERROR")
+
+	counts := map[api.ActionStatus]int{}
+	for _, outcome := range outcomes {
+		counts[outcome.Status]++
+
+		reason := ""
+		if outcome.Err != nil {
+			reason = outcome.Err.Error()
+		}
+
+		fmt.Fprintf(
+			table, "%s\t%s\t%s\t%s\t%s\n",
+			outcome.Status, outcome.Action.Type, outcome.Action.CategoryTitle,
+			outcome.Action.FeedURL, reason,
+		)
+	}
+
+	fmt.Fprintf(
+		table, "\n%d succeeded, %d failed, %d skipped",
+		counts[api.ActionSucceeded], counts[api.ActionFailed], counts[api.ActionSkipped],
+	)
+
+	if counts[api.ActionRolledBack] > 0 {
+		fmt.Fprintf(table, ", %d rolled back", counts[api.ActionRolledBack])
+	}
+
+	fmt.Fprintln(table)
+
+	if err := table.Flush(); err != nil {
+		return errors.Wrap(err, "writing outcome summary")
+	}
+
+	return nil
+}
@@ -2,6 +2,7 @@ package cmd
 
 import (
 	"context"
+	"fmt"
 	"path/filepath"
 	"strings"
 
@@ -9,26 +10,141 @@ import (
 	"github.com/revett/miniflux-sync/api"
 	"github.com/revett/miniflux-sync/config"
 	"github.com/revett/miniflux-sync/diff"
+	"github.com/revett/miniflux-sync/journal"
 	"github.com/revett/miniflux-sync/log"
 	"github.com/revett/miniflux-sync/parse"
 	miniflux "miniflux.app/v2/client"
 )
 
-func sync( //nolint:cyclop,funlen
-	ctx context.Context, flags *config.SyncFlags, client *miniflux.Client,
+func sync(
+	ctx context.Context, flags *config.SyncFlags, client api.MinifluxClient, retry api.RetryPolicy,
 ) error {
+	if flags.Resume {
+		return resume(ctx, flags, client, retry)
+	}
+
+	result, err := calculatePlan(ctx, flags.Path, flags.UnsupportedOptions, client, retry)
+	if err != nil {
+		return err
+	}
+
+	if err := renderPlan(ctx, flags.Output, result); err != nil {
+		return errors.Wrap(err, "rendering plan")
+	}
+
+	if len(result.actions) == 0 {
+		return nil
+	}
+
+	if flags.DryRun {
+		log.Info(ctx, "dry run complete")
+		return nil
+	}
+
+	var j *journal.Journal
+	if flags.Journaled() {
+		j, err = journal.Create(ctx, flags.JournalPath(), result.actions)
+		if err != nil {
+			return errors.Wrap(err, "creating journal")
+		}
+	}
+
+	return performActions(ctx, flags, client, result, j, retry)
+}
+
+// performActions performs the planned actions, recording their progress in the journal, if j is
+// not nil. The journal is removed once every action has succeeded or been rolled back, and kept
+// otherwise so the sync can be resumed.
+func performActions(
+	ctx context.Context,
+	flags *config.SyncFlags,
+	client api.MinifluxClient,
+	result *planResult,
+	j *journal.Journal,
+	retry api.RetryPolicy,
+) error {
+	outcomes, err := api.Update(
+		ctx, client, result.actions, result.feeds, result.categories, api.UpdateOptions{
+			Concurrency:       flags.Concurrency,
+			KeepGoing:         flags.KeepGoing,
+			Retry:             retry,
+			Journal:           j,
+			RollbackOnFailure: flags.RollbackOnFailure,
+		},
+	)
+
+	if (flags.KeepGoing || flags.RollbackOnFailure) && outcomes != nil {
+		if err := writeOutcomeSummary(outputWriter(flags.Output), outcomes); err != nil {
+			return err
+		}
+	}
+
+	// A sync which was rolled back has nothing left to resume.
+	if err != nil && flags.RollbackOnFailure && !errors.Is(err, api.ErrRollbackFailed) {
+		if removeErr := j.Remove(); removeErr != nil {
+			log.Error(ctx, errors.Wrap(removeErr, "removing journal"))
+		}
+
+		return errors.Wrap(err, "performing actions, rolled back")
+	}
+
+	if err != nil {
+		if closeErr := j.Close(); closeErr != nil {
+			log.Error(ctx, closeErr)
+		}
+
+		if j != nil {
+			log.Warn(ctx, `sync did not finish, run again with "--resume" to finish it`, log.Metadata{
+				"journal": flags.JournalPath(),
+			})
+		}
+
+		return errors.Wrap(err, "performing actions")
+	}
+
+	if err := j.Remove(); err != nil {
+		return errors.Wrap(err, "removing journal")
+	}
+
+	return nil
+}
+
+// planResult holds the actions calculated between the local and remote state, along with the
+// remote data they were calculated against.
+type planResult struct {
+	actions     []diff.Action
+	categories  []*miniflux.Category
+	feeds       []*miniflux.Feed
+	localState  *diff.State
+	remoteState *diff.State
+}
+
+// calculatePlan loads the local state from the YAML file at path, fetches the remote state, and
+// calculates the actions required to sync them. Feed options which the server does not support are
+// handled according to unsupportedOptions.
+func calculatePlan(
+	ctx context.Context,
+	path string,
+	unsupportedOptions string,
+	client api.MinifluxClient,
+	retry api.RetryPolicy,
+) (*planResult, error) {
 	var localState *diff.State
 	var err error
 
-	switch filepath.Ext(flags.Path) {
+	switch filepath.Ext(path) {
 	case ".yaml", ".yml":
-		localState, err = parse.Parse(ctx, flags.Path)
+		localState, err = parse.Parse(ctx, path)
 		if err != nil {
-			return errors.Wrap(err, "loading data from yaml file")
+			return nil, errors.Wrap(err, "loading data from yaml file")
 		}
 
 	default:
-		return errors.New("invalid file extension") // Should never happen, as we validate flag before.
+		return nil, errors.New("invalid file extension") // Should never happen, as we validate flag before.
+	}
+
+	if err := checkFeedOptions(ctx, localState, client, unsupportedOptions); err != nil {
+		return nil, err
 	}
 
 	log.Info(ctx, "local feeds", log.Metadata{
@@ -38,14 +154,76 @@ func sync( //nolint:cyclop,funlen
 		"count": len(localState.CategoryTitles()),
 	})
 
-	feeds, categories, err := api.FetchData(ctx, client)
+	feeds, categories, remoteState, err := fetchRemoteState(ctx, client, retry)
+	if err != nil {
+		return nil, err
+	}
+
+	actions, err := diff.CalculateDiff(localState, remoteState)
+	if err != nil {
+		return nil, errors.Wrap(err, "calculating diff")
+	}
+
+	return &planResult{
+		actions:     actions,
+		categories:  categories,
+		feeds:       feeds,
+		localState:  localState,
+		remoteState: remoteState,
+	}, nil
+}
+
+// checkFeedOptions removes the feed options which the Miniflux server is too old to support from
+// the local state, with a warning for each, as the server would ignore them and they would never be
+// in sync. If mode is fail, an error listing them is returned instead.
+func checkFeedOptions(
+	ctx context.Context, localState *diff.State, client api.MinifluxClient, mode string,
+) error {
+	version := api.DetectedVersion(client)
+
+	unsupported := api.RemoveUnsupportedOptions(localState, version)
+	if len(unsupported) == 0 {
+		return nil
+	}
+
+	if mode == config.UnsupportedOptionsFail {
+		descriptions := make([]string, 0, len(unsupported))
+		for _, option := range unsupported {
+			descriptions = append(descriptions, fmt.Sprintf(
+				`"%s" for %s (since %s)`, option.Option, option.FeedURL, option.Since,
+			))
+		}
+
+		return errors.Errorf(
+			"miniflux %s does not support feed options: %s", version, strings.Join(descriptions, ", "),
+		)
+	}
+
+	for _, option := range unsupported {
+		log.Warn(ctx, "ignoring feed option not supported by miniflux version", log.Metadata{
+			"feed_url": option.FeedURL,
+			"option":   option.Option,
+			"since":    option.Since.String(),
+			"version":  version.String(),
+		})
+	}
+
+	return nil
+}
+
+// fetchRemoteState fetches the feeds and categories from the Miniflux instance, and generates the
+// remote state from them.
+func fetchRemoteState(
+	ctx context.Context, client api.MinifluxClient, retry api.RetryPolicy,
+) ([]*miniflux.Feed, []*miniflux.Category, *diff.State, error) {
+	feeds, categories, err := api.FetchData(ctx, client, retry)
 	if err != nil {
-		return errors.Wrap(err, "fetching data")
+		return nil, nil, nil, errors.Wrap(err, "fetching data")
 	}
 
 	remoteState, err := api.GenerateDiffState(feeds, categories)
 	if err != nil {
-		return errors.Wrap(err, "generating remote state")
+		return nil, nil, nil, errors.Wrap(err, "generating remote state")
 	}
 
 	log.Info(ctx, "remote feeds", log.Metadata{
@@ -55,16 +233,10 @@ func sync( //nolint:cyclop,funlen
 		"count": len(remoteState.CategoryTitles()),
 	})
 
-	actions, err := diff.CalculateDiff(localState, remoteState)
-	if err != nil {
-		return errors.Wrap(err, "calculating diff")
-	}
-
-	if len(actions) == 0 {
-		log.Info(ctx, "no actions to perform")
-		return nil
-	}
+	return feeds, categories, remoteState, nil
+}
 
+func logActions(ctx context.Context, actions []diff.Action) {
 	log.Info(ctx, "actions to perform", log.Metadata{
 		"count": len(actions),
 	})
@@ -74,16 +246,12 @@ func sync( //nolint:cyclop,funlen
 			"category_title": action.CategoryTitle,
 			"feed_url":       action.FeedURL,
 		})
-	}
 
-	if flags.DryRun {
-		log.Info(ctx, "dry run complete")
-		return nil
-	}
-
-	if err := api.Update(ctx, client, actions, feeds, categories); err != nil {
-		return errors.Wrap(err, "performing actions")
+		// Show which options are changing, with secret values masked.
+		if action.Type == diff.UpdateFeed {
+			for _, change := range action.OptionChanges() {
+				log.Info(ctx, "  "+change.String())
+			}
+		}
 	}
-
-	return nil
 }
@@ -0,0 +1,243 @@
+package cmd
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/revett/miniflux-sync/api"
+	"github.com/revett/miniflux-sync/config"
+	"github.com/revett/miniflux-sync/log"
+	"github.com/revett/miniflux-sync/minifluxtest"
+	"github.com/revett/miniflux-sync/parse"
+	"github.com/stretchr/testify/require"
+	miniflux "miniflux.app/v2/client"
+)
+
+const testFeedsYAML = `news:
+  - https://example.com/news.xml
+  - url: https://example.com/tech.xml
+    crawler: true
+blogs:
+  - https://example.com/blog.xml
+`
+
+// newTestServer starts a fake Miniflux server with one category and feed which the test feeds
+// file does not contain, and returns it with a client connected to it.
+func newTestServer(t *testing.T) (*minifluxtest.Server, api.MinifluxClient) {
+	t.Helper()
+
+	server := minifluxtest.NewServer()
+	t.Cleanup(server.Close)
+
+	server.AddFeed("old", miniflux.Feed{FeedURL: "https://example.com/old.xml"})
+
+	logger := log.New()
+	ctx := logger.WithContext(context.Background())
+
+	client, err := api.Client(
+		ctx, &config.GlobalFlags{Endpoint: server.URL, APIKey: minifluxtest.APIKey, Burst: 1}, nil,
+	)
+	require.NoError(t, err)
+
+	return server, client
+}
+
+func writeFeedsFile(t *testing.T) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "feeds.yml")
+	require.NoError(t, os.WriteFile(path, []byte(testFeedsYAML), 0o600))
+
+	return path
+}
+
+func remoteFeedURLs(server *minifluxtest.Server) map[string][]string {
+	urls := map[string][]string{}
+
+	for _, category := range server.Categories() {
+		urls[category.Title] = []string{}
+	}
+
+	for _, feed := range server.Feeds() {
+		urls[feed.Category.Title] = append(urls[feed.Category.Title], feed.FeedURL)
+	}
+
+	for _, feedURLs := range urls {
+		slices.Sort(feedURLs)
+	}
+
+	return urls
+}
+
+func TestSync(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		concurrency int
+		latency     time.Duration
+		fault       *minifluxtest.Fault
+	}{
+		"Sequential": {
+			concurrency: 1,
+		},
+		"ConcurrentWithLatency": {
+			concurrency: 4, //nolint:mnd
+			latency:     20 * time.Millisecond,
+		},
+		"TransientErrorRetried": {
+			concurrency: 1,
+			fault: &minifluxtest.Fault{
+				Method:     http.MethodPost,
+				Path:       "/v1/feeds",
+				StatusCode: http.StatusBadGateway,
+				Times:      1,
+			},
+		},
+	}
+
+	for name, testCase := range testCases {
+		tc := testCase
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			server, client := newTestServer(t)
+			server.SetLatency(tc.latency)
+			if tc.fault != nil {
+				server.InjectFault(*tc.fault)
+			}
+
+			logger := log.New()
+			ctx := logger.WithContext(context.Background())
+
+			path := writeFeedsFile(t)
+			retry := api.RetryPolicy{MaxRetries: 2, InitialBackoff: time.Millisecond}
+
+			err := sync(ctx, &config.SyncFlags{
+				Concurrency: tc.concurrency,
+				Output:      config.OutputText,
+				Path:        path,
+			}, client, retry)
+			require.NoError(t, err)
+
+			require.Equal(t, map[string][]string{
+				"news":  {"https://example.com/news.xml", "https://example.com/tech.xml"},
+				"blogs": {"https://example.com/blog.xml"},
+			}, remoteFeedURLs(server))
+
+			for _, feed := range server.Feeds() {
+				require.Equal(t, feed.FeedURL == "https://example.com/tech.xml", feed.Crawler)
+			}
+
+			require.NoFileExists(t, path+".journal")
+
+			result, err := calculatePlan(ctx, path, config.UnsupportedOptionsWarn, client, retry)
+			require.NoError(t, err)
+			require.Empty(t, result.actions)
+		})
+	}
+}
+
+func TestSync_Failure(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		journal bool
+	}{
+		"WithJournal": {
+			journal: true,
+		},
+		"WithoutJournal": {
+			journal: false,
+		},
+	}
+
+	for name, testCase := range testCases {
+		tc := testCase
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			server, client := newTestServer(t)
+			server.InjectFault(minifluxtest.Fault{
+				Method:     http.MethodPost,
+				Path:       "/v1/feeds",
+				StatusCode: http.StatusBadRequest,
+				Message:    "invalid feed",
+			})
+
+			logger := log.New()
+			ctx := logger.WithContext(context.Background())
+
+			path := writeFeedsFile(t)
+			flags := &config.SyncFlags{
+				Concurrency: 1,
+				Journal:     tc.journal,
+				Output:      config.OutputText,
+				Path:        path,
+			}
+			retry := api.RetryPolicy{MaxRetries: 2, InitialBackoff: time.Millisecond}
+
+			err := sync(ctx, flags, client, retry)
+			require.ErrorContains(t, err, "invalid feed")
+
+			// A bad request is not retried.
+			require.Equal(t, 1, countRequests(server, "POST /v1/feeds"))
+
+			if !tc.journal {
+				// Without a journal, the next sync plans again from the current state.
+				require.NoFileExists(t, path+".journal")
+				require.ErrorContains(t, sync(ctx, flags, client, retry), "invalid feed")
+
+				return
+			}
+
+			// The journal is kept so the sync can be resumed, and a new sync refuses to start.
+			require.FileExists(t, path+".journal")
+			require.ErrorContains(
+				t, sync(ctx, flags, client, retry), "journal from an interrupted sync exists",
+			)
+		})
+	}
+}
+
+func TestDump(t *testing.T) {
+	t.Parallel()
+
+	server, client := newTestServer(t)
+	server.AddFeed("news", miniflux.Feed{FeedURL: "https://example.com/news.xml", Crawler: true})
+
+	logger := log.New()
+	ctx := logger.WithContext(context.Background())
+
+	path := filepath.Join(t.TempDir(), "dump.yml")
+
+	err := dump(ctx, &config.DumpFlags{Path: path}, client, api.RetryPolicy{})
+	require.NoError(t, err)
+
+	state, err := parse.Parse(ctx, path)
+	require.NoError(t, err)
+
+	require.Equal(t, map[string][]string{
+		"old":  {"https://example.com/old.xml"},
+		"news": {"https://example.com/news.xml"},
+	}, state.FeedURLsByCategoryTitle)
+	require.True(t, *state.FeedsByCategoryTitle["news"][0].Options.Crawler)
+}
+
+func countRequests(server *minifluxtest.Server, request string) int {
+	count := 0
+
+	for _, r := range server.Requests() {
+		if r == request {
+			count++
+		}
+	}
+
+	return count
+}
@@ -0,0 +1,162 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"slices"
+	"text/tabwriter"
+
+	"github.com/pkg/errors"
+	"github.com/revett/miniflux-sync/api"
+	"github.com/revett/miniflux-sync/config"
+	"github.com/revett/miniflux-sync/log"
+)
+
+// targetOutcome is the result of syncing to one target.
+type targetOutcome struct {
+	name     string
+	endpoint string
+	err      error
+}
+
+// syncTargets syncs to each target in turn, where each target is a profile from the config file.
+// A target which fails does not stop the others, and the run fails if any target failed.
+func syncTargets(ctx context.Context, cfg *config.GlobalFlags, flags *config.SyncFlags) error {
+	if err := validateTargets(cfg, flags); err != nil {
+		return err
+	}
+
+	outcomes := make([]targetOutcome, 0, len(flags.Targets))
+
+	for _, name := range flags.Targets {
+		targetCtx := log.WithMetadata(ctx, log.Metadata{
+			"target": name,
+		})
+
+		log.Info(targetCtx, "syncing target")
+
+		outcome := syncTarget(targetCtx, cfg, flags, name)
+		if outcome.err != nil {
+			log.Error(targetCtx, outcome.err)
+		}
+
+		outcomes = append(outcomes, outcome)
+	}
+
+	if err := writeTargetSummary(outputWriter(flags.Output), outcomes, flags.DryRun); err != nil {
+		return err
+	}
+
+	failed := 0
+	for _, outcome := range outcomes {
+		if outcome.err != nil {
+			failed++
+		}
+	}
+
+	if failed > 0 {
+		return errors.Errorf("%d of %d targets failed", failed, len(outcomes))
+	}
+
+	return nil
+}
+
+// syncTarget syncs to the instance of the named profile. The endpoint and credentials always come
+// from the profile, whilst the path comes from the flags if set, so every target can share one file.
+func syncTarget(
+	ctx context.Context, cfg *config.GlobalFlags, flags *config.SyncFlags, name string,
+) targetOutcome {
+	targetCfg := *cfg
+	targetCfg.Profile = name
+
+	targetFlags := *flags
+	targetFlags.Targets = nil
+
+	if err := resolveConfig(ctx, &targetCfg, &targetFlags); err != nil {
+		return targetOutcome{name: name, err: err}
+	}
+
+	// Each target has its own journal, so each can be resumed on its own.
+	if flags.Journal || flags.Resume {
+		targetFlags.JournalFile = targetFlags.Path + "." + name + ".journal"
+	}
+
+	outcome := targetOutcome{name: name, endpoint: targetCfg.Endpoint}
+
+	client, err := newClient(ctx, &targetCfg, targetFlags.Path)
+	if err != nil {
+		outcome.err = err
+		return outcome
+	}
+
+	if err := sync(ctx, &targetFlags, client, api.NewRetryPolicy(&targetCfg)); err != nil {
+		outcome.err = errors.Wrap(err, "running sync command")
+	}
+
+	return outcome
+}
+
+// validateTargets checks that the flags can be used with several targets.
+func validateTargets(cfg *config.GlobalFlags, flags *config.SyncFlags) error {
+	if cfg.Endpoint != "" || cfg.APIKey != "" || cfg.APIKeyFile != "" || cfg.APIKeyCommand != "" ||
+		cfg.Username != "" || cfg.Password != "" || cfg.PasswordFile != "" {
+		return errors.New(
+			`"--endpoint" and credentials cannot be used with "--target", set them in each profile`,
+		)
+	}
+
+	if flags.JournalFile != "" {
+		return errors.New(`"--journal-file" cannot be used with "--target"`)
+	}
+
+	if flags.Output == config.OutputJSON {
+		return errors.New(`"--output json" cannot be used with "--target"`)
+	}
+
+	if len(flags.Targets) > 1 && (cfg.Record != "" || cfg.Replay != "") {
+		return errors.New(`"--record" and "--replay" cannot be used with more than one target`)
+	}
+
+	for i, name := range flags.Targets {
+		if slices.Contains(flags.Targets[:i], name) {
+			return errors.Errorf(`target "%s" is listed more than once`, name)
+		}
+	}
+
+	return nil
+}
+
+// writeTargetSummary writes a table of the result of syncing to each target.
+func writeTargetSummary(w io.Writer, outcomes []targetOutcome, dryRun bool) error {
+	table := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0) //nolint:mnd
+
+	fmt.Fprintln(table, "TARGET\tENDPOINT\tSTATUS\tERROR")
+
+	succeeded := "synced"
+	if dryRun {
+		succeeded = "planned"
+	}
+
+	failed := 0
+	for _, outcome := range outcomes {
+		status := succeeded
+		reason := ""
+
+		if outcome.err != nil {
+			status = "failed"
+			reason = outcome.err.Error()
+			failed++
+		}
+
+		fmt.Fprintf(table, "%s\t%s\t%s\t%s\n", outcome.name, outcome.endpoint, status, reason)
+	}
+
+	fmt.Fprintf(table, "\n%d %s, %d failed\n", len(outcomes)-failed, succeeded, failed)
+
+	if err := table.Flush(); err != nil {
+		return errors.Wrap(err, "writing target summary")
+	}
+
+	return nil
+}
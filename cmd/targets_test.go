@@ -0,0 +1,121 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/revett/miniflux-sync/config"
+	"github.com/revett/miniflux-sync/log"
+	"github.com/revett/miniflux-sync/minifluxtest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSyncTargets(t *testing.T) {
+	t.Parallel()
+
+	servers := map[string]*minifluxtest.Server{}
+	for _, name := range []string{"team-a", "team-b", "broken"} {
+		servers[name] = minifluxtest.NewServer()
+		t.Cleanup(servers[name].Close)
+	}
+
+	servers["broken"].InjectFault(minifluxtest.Fault{
+		Method:     http.MethodPost,
+		Path:       "/v1/feeds",
+		StatusCode: http.StatusBadRequest,
+		Message:    "invalid feed",
+	})
+
+	configFile := "profiles:\n"
+	for name, server := range servers {
+		configFile += fmt.Sprintf(
+			"  %s:\n    endpoint: %s\n    api_key: %s\n", name, server.URL, minifluxtest.APIKey,
+		)
+	}
+
+	configPath := filepath.Join(t.TempDir(), "config.yml")
+	require.NoError(t, os.WriteFile(configPath, []byte(configFile), 0o600))
+
+	logger := log.New()
+	ctx := logger.WithContext(context.Background())
+
+	path := writeFeedsFile(t)
+
+	err := syncTargets(ctx, &config.GlobalFlags{Config: configPath, Burst: 1}, &config.SyncFlags{
+		Concurrency: 1,
+		Output:      config.OutputText,
+		Journal:     true,
+		Path:        path,
+		Targets:     []string{"team-a", "broken", "team-b"},
+	})
+	require.EqualError(t, err, "1 of 3 targets failed")
+
+	expected := map[string][]string{
+		"news":  {"https://example.com/news.xml", "https://example.com/tech.xml"},
+		"blogs": {"https://example.com/blog.xml"},
+	}
+
+	require.Equal(t, expected, remoteFeedURLs(servers["team-a"]))
+	require.Equal(t, expected, remoteFeedURLs(servers["team-b"]))
+
+	// Only the failed target keeps a journal, to be resumed on its own.
+	require.FileExists(t, path+".broken.journal")
+	require.NoFileExists(t, path+".team-a.journal")
+	require.NoFileExists(t, path+".team-b.journal")
+}
+
+func TestValidateTargets(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		cfg   config.GlobalFlags
+		flags config.SyncFlags
+		err   string
+	}{
+		"Valid": {
+			flags: config.SyncFlags{Targets: []string{"a", "b"}},
+		},
+		"Endpoint": {
+			cfg:   config.GlobalFlags{Endpoint: "https://example.com"},
+			flags: config.SyncFlags{Targets: []string{"a"}},
+			err:   `"--endpoint" and credentials cannot be used with "--target", set them in each profile`,
+		},
+		"JournalFile": {
+			flags: config.SyncFlags{Targets: []string{"a"}, JournalFile: "sync.journal"},
+			err:   `"--journal-file" cannot be used with "--target"`,
+		},
+		"JSONOutput": {
+			flags: config.SyncFlags{Targets: []string{"a"}, Output: config.OutputJSON},
+			err:   `"--output json" cannot be used with "--target"`,
+		},
+		"RecordSeveral": {
+			cfg:   config.GlobalFlags{Record: "session.jsonl"},
+			flags: config.SyncFlags{Targets: []string{"a", "b"}},
+			err:   `"--record" and "--replay" cannot be used with more than one target`,
+		},
+		"Duplicate": {
+			flags: config.SyncFlags{Targets: []string{"a", "b", "a"}},
+			err:   `target "a" is listed more than once`,
+		},
+	}
+
+	for name, testCase := range tests {
+		tc := testCase
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			err := validateTargets(&tc.cfg, &tc.flags)
+			if tc.err != "" {
+				require.EqualError(t, err, tc.err)
+				return
+			}
+
+			require.NoError(t, err)
+		})
+	}
+}
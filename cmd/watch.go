@@ -0,0 +1,191 @@
+package cmd
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/pkg/errors"
+	"github.com/revett/miniflux-sync/config"
+	"github.com/revett/miniflux-sync/log"
+)
+
+// watch syncs the data file, and then syncs it again whenever it changes, until ctx is cancelled.
+// A failed sync is logged, and the next change is synced as normal.
+func watch(ctx context.Context, cfg *config.GlobalFlags, flags *config.SyncFlags) error {
+	if err := validateWatch(flags); err != nil {
+		return err
+	}
+
+	if err := resolveConfig(ctx, cfg, flags); err != nil {
+		return err
+	}
+
+	journalPath := flags.JournalPath()
+	if _, err := os.Stat(journalPath); flags.Journaled() && err == nil {
+		return errors.Errorf(
+			`journal from an interrupted sync exists, resume it with "--resume" before watching: "%s"`,
+			journalPath,
+		)
+	}
+
+	return watchFile(ctx, flags.Path, flags.WatchDebounce, func(cycleCtx context.Context) error {
+		cycleCtx, cancel := withTimeout(cycleCtx, cfg)
+		defer cancel()
+
+		err := runSync(cycleCtx, cfg, flags)
+
+		// The plan is calculated again from the current state on the next change, so the journal of a
+		// failed sync is not needed, unless watching is stopping and the sync may be resumed.
+		if err != nil && ctx.Err() == nil {
+			removeJournal(ctx, journalPath)
+		}
+
+		return err
+	})
+}
+
+// validateWatch checks that the flags can be used with "--watch".
+func validateWatch(flags *config.SyncFlags) error {
+	if len(flags.Targets) > 0 {
+		return errors.New(`"--watch" cannot be used with "--target"`)
+	}
+
+	if flags.Resume {
+		return errors.New(`"--watch" cannot be used with "--resume", resume the sync before watching`)
+	}
+
+	return nil
+}
+
+// removeJournal removes the journal at path, if it exists.
+func removeJournal(ctx context.Context, path string) {
+	err := os.Remove(path)
+	if os.IsNotExist(err) {
+		return
+	}
+
+	if err != nil {
+		log.Error(ctx, errors.Wrap(err, "removing journal"))
+		return
+	}
+
+	log.Warn(ctx, "removed journal of failed sync, the next sync will plan from the current state")
+}
+
+// watchFile calls sync once, and then again each time the file at path changes, until ctx is
+// cancelled. Changes are debounced, so a burst of writes only syncs once, and changes which leave
+// the content as it was last synced are ignored. Errors from sync are logged, rather than returned.
+func watchFile(
+	ctx context.Context, path string, debounce time.Duration, sync func(context.Context) error,
+) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return errors.Wrap(err, "creating file watcher")
+	}
+	defer watcher.Close()
+
+	path = filepath.Clean(path)
+
+	// The directory is watched, as editors often save by replacing the file, which would end a watch
+	// on the file itself.
+	if err := watcher.Add(filepath.Dir(path)); err != nil {
+		return errors.Wrap(err, "watching data file directory")
+	}
+
+	cycle := 0
+	syncedDigest := ""
+
+	runCycle := func() {
+		digest, err := fileDigest(path)
+		if err == nil && digest == syncedDigest {
+			log.Info(ctx, "data file content unchanged, skipping sync")
+			return
+		}
+
+		cycle++
+
+		log.Info(ctx, "starting sync", log.Metadata{
+			"cycle": cycle,
+		})
+
+		if err := sync(ctx); err != nil {
+			log.Error(ctx, errors.Wrap(err, "sync failed"))
+			log.Warn(ctx, "waiting for the data file to change", log.Metadata{
+				"cycle": cycle,
+			})
+
+			return
+		}
+
+		syncedDigest = digest
+
+		log.Info(ctx, "sync finished, waiting for the data file to change", log.Metadata{
+			"cycle": cycle,
+		})
+	}
+
+	runCycle()
+
+	var debounceTimer *time.Timer
+	var debounced <-chan time.Time
+
+	for {
+		select {
+		case <-ctx.Done():
+			if debounceTimer != nil {
+				debounceTimer.Stop()
+			}
+
+			log.Info(ctx, "stopped watching data file")
+
+			return nil
+
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return errors.New("file watcher closed")
+			}
+
+			if filepath.Clean(event.Name) != path || event.Op == fsnotify.Chmod {
+				continue
+			}
+
+			if debounceTimer != nil {
+				debounceTimer.Stop()
+			}
+
+			debounceTimer = time.NewTimer(debounce)
+			debounced = debounceTimer.C
+
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return errors.New("file watcher closed")
+			}
+
+			log.Warn(ctx, "file watcher error", log.Metadata{
+				"error": err.Error(),
+			})
+
+		case <-debounced:
+			debounceTimer, debounced = nil, nil
+
+			runCycle()
+		}
+	}
+}
+
+// fileDigest returns the SHA-256 digest of the content of the file at path.
+func fileDigest(path string) (string, error) {
+	data, err := os.ReadFile(path) //nolint:gosec
+	if err != nil {
+		return "", errors.Wrap(err, "reading data file")
+	}
+
+	sum := sha256.Sum256(data)
+
+	return hex.EncodeToString(sum[:]), nil
+}
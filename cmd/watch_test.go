@@ -0,0 +1,129 @@
+package cmd
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/revett/miniflux-sync/config"
+	"github.com/revett/miniflux-sync/log"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWatchFile(t *testing.T) {
+	t.Parallel()
+
+	logger := log.New()
+	ctx, cancel := context.WithCancel(logger.WithContext(context.Background()))
+	defer cancel()
+
+	path := writeFeedsFile(t)
+
+	// The second sync fails, as if the edited file could not be parsed.
+	cycles := make(chan int, 10) //nolint:mnd
+	calls := 0
+	sync := func(context.Context) error {
+		calls++
+		cycles <- calls
+
+		if calls == 2 {
+			return errors.New("parsing yaml")
+		}
+
+		return nil
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- watchFile(ctx, path, 50*time.Millisecond, sync) //nolint:mnd
+	}()
+
+	expectCycle := func(expected int) {
+		t.Helper()
+
+		select {
+		case cycle := <-cycles:
+			require.Equal(t, expected, cycle)
+		case <-time.After(5 * time.Second): //nolint:mnd
+			require.FailNow(t, "timed out waiting for sync", "cycle %d", expected)
+		}
+	}
+
+	expectNoCycle := func() {
+		t.Helper()
+
+		select {
+		case cycle := <-cycles:
+			require.FailNow(t, "unexpected sync", "cycle %d", cycle)
+		case <-time.After(300 * time.Millisecond): //nolint:mnd
+		}
+	}
+
+	write := func(path string, content string) {
+		t.Helper()
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	}
+
+	expectCycle(1)
+
+	// A burst of writes is synced once.
+	write(path, testFeedsYAML+"# one\n")
+	write(path, testFeedsYAML+"# two\n")
+	write(path, testFeedsYAML+"# three\n")
+	expectCycle(2)
+	expectNoCycle()
+
+	// The same content is synced again after a failed sync, but not after a successful one.
+	write(path, testFeedsYAML+"# three\n")
+	expectCycle(3)
+
+	write(path, testFeedsYAML+"# three\n")
+	expectNoCycle()
+
+	// Replacing the file, as many editors do when saving, is also a change.
+	write(path+".tmp", testFeedsYAML)
+	require.NoError(t, os.Rename(path+".tmp", path))
+	expectCycle(4)
+
+	cancel()
+	require.NoError(t, <-done)
+}
+
+func TestValidateWatch(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		flags config.SyncFlags
+		err   string
+	}{
+		"Valid": {
+			flags: config.SyncFlags{Watch: true},
+		},
+		"Targets": {
+			flags: config.SyncFlags{Watch: true, Targets: []string{"a"}},
+			err:   `"--watch" cannot be used with "--target"`,
+		},
+		"Resume": {
+			flags: config.SyncFlags{Watch: true, Resume: true},
+			err:   `"--watch" cannot be used with "--resume"`,
+		},
+	}
+
+	for name, testCase := range tests {
+		tc := testCase
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			err := validateWatch(&tc.flags)
+			if tc.err != "" {
+				require.ErrorContains(t, err, tc.err)
+				return
+			}
+
+			require.NoError(t, err)
+		})
+	}
+}
@@ -0,0 +1,35 @@
+package config
+
+import (
+	"context"
+
+	"github.com/urfave/cli/v2"
+)
+
+// CheckFlags holds the flags for the check command.
+type CheckFlags struct {
+	Path               string
+	UnsupportedOptions string
+}
+
+// Flags returns the flags for the check command.
+func (c *CheckFlags) Flags(ctx context.Context) []cli.Flag {
+	return []cli.Flag{
+		&cli.StringFlag{
+			Name:        "path",
+			Usage:       "Path to file for imported data. (required, unless set by the profile)",
+			EnvVars:     []string{"MINIFLUX_SYNC_PATH"},
+			Destination: &c.Path,
+			Aliases:     []string{"p"},
+			Action: func(_ *cli.Context, path string) error {
+				return validateInputFile(ctx, path, []string{".yaml", ".yml"})
+			},
+		},
+		unsupportedOptionsFlag(&c.UnsupportedOptions),
+	}
+}
+
+// Resolve sets the path from the profile if it was not set, and checks that it is set.
+func (c *CheckFlags) Resolve(ctx context.Context, cfg *GlobalFlags) error {
+	return resolveInputPath(ctx, &c.Path, cfg)
+}
@@ -0,0 +1,109 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/revett/miniflux-sync/log"
+)
+
+// loadCredentials reads the API key from the file or command which holds it, or the password from
+// its file, if any is set. The file or command is cleared once read, so it is only read once.
+func (c *GlobalFlags) loadCredentials(ctx context.Context) error {
+	switch {
+	case c.APIKeyFile != "":
+		apiKey, err := readAPIKeyFile(c.APIKeyFile)
+		if err != nil {
+			return err
+		}
+
+		c.APIKey, c.APIKeyFile = apiKey, ""
+
+	case c.APIKeyCommand != "":
+		apiKey, err := runAPIKeyCommand(ctx, c.APIKeyCommand)
+		if err != nil {
+			return err
+		}
+
+		c.APIKey, c.APIKeyCommand = apiKey, ""
+
+	case c.PasswordFile != "":
+		password, err := readPasswordFile(c.PasswordFile)
+		if err != nil {
+			return err
+		}
+
+		c.Password, c.PasswordFile = password, ""
+	}
+
+	return nil
+}
+
+// readAPIKeyFile reads the API key from a file, ignoring surrounding whitespace such as the
+// trailing newline of secret files.
+func readAPIKeyFile(path string) (string, error) {
+	dat, err := os.ReadFile(path)
+	if err != nil {
+		return "", errors.Wrap(err, "reading api key file")
+	}
+
+	apiKey := strings.TrimSpace(string(dat))
+	if apiKey == "" {
+		return "", errors.Errorf(`api key file "%s" is empty`, path)
+	}
+
+	return apiKey, nil
+}
+
+// readPasswordFile reads the password from a file. Only the trailing newline of secret files is
+// removed, as a password can start or end with spaces.
+func readPasswordFile(path string) (string, error) {
+	dat, err := os.ReadFile(path)
+	if err != nil {
+		return "", errors.Wrap(err, "reading password file")
+	}
+
+	password := strings.TrimSuffix(strings.TrimSuffix(string(dat), "\n"), "\r")
+	if password == "" {
+		return "", errors.Errorf(`password file "%s" is empty`, path)
+	}
+
+	return password, nil
+}
+
+// runAPIKeyCommand runs a command with the shell, and returns the first line of its output as the
+// API key. The command can prompt the user, as stdin and stderr are passed through.
+func runAPIKeyCommand(ctx context.Context, command string) (string, error) {
+	log.Info(ctx, "running command for api key")
+
+	shell, flag := "sh", "-c"
+	if runtime.GOOS == "windows" {
+		shell, flag = "cmd", "/C"
+	}
+
+	var stdout bytes.Buffer
+
+	cmd := exec.CommandContext(ctx, shell, flag, command) //nolint:gosec
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = &stdout
+	cmd.Stderr = os.Stderr
+
+	if err := cmd.Run(); err != nil {
+		return "", errors.Wrap(err, "running api key command")
+	}
+
+	// Password managers such as pass print other details after the first line.
+	apiKey, _, _ := strings.Cut(stdout.String(), "\n")
+	apiKey = strings.TrimSpace(apiKey)
+
+	if apiKey == "" {
+		return "", errors.New("api key command printed nothing")
+	}
+
+	return apiKey, nil
+}
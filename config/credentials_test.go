@@ -0,0 +1,202 @@
+package config
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/revett/miniflux-sync/log"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGlobalFlagsResolve_APIKeySources(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+
+	keyPath := filepath.Join(dir, "api_key")
+	require.NoError(t, os.WriteFile(keyPath, []byte("file-key\n"), 0o600))
+
+	emptyPath := filepath.Join(dir, "empty")
+	require.NoError(t, os.WriteFile(emptyPath, []byte("\n"), 0o600))
+
+	configPath := filepath.Join(dir, "config.yml")
+	require.NoError(t, os.WriteFile(configPath, []byte(`profiles:
+  file:
+    endpoint: https://example.com
+    api_key_file: api_key
+  command:
+    endpoint: https://example.com
+    api_key_command: echo profile-key
+`), 0o600))
+
+	tests := map[string]struct {
+		flags    GlobalFlags
+		expected string
+		err      string
+	}{
+		"File": {
+			flags:    GlobalFlags{Endpoint: "https://example.com", APIKeyFile: keyPath},
+			expected: "file-key",
+		},
+		"Command": {
+			flags:    GlobalFlags{Endpoint: "https://example.com", APIKeyCommand: `printf 'command-key\nurl: x\n'`},
+			expected: "command-key",
+		},
+		"ProfileFileRelativeToConfig": {
+			flags:    GlobalFlags{Profile: "file"},
+			expected: "file-key",
+		},
+		"ProfileCommand": {
+			flags:    GlobalFlags{Profile: "command"},
+			expected: "profile-key",
+		},
+		"EmptyFile": {
+			flags: GlobalFlags{Endpoint: "https://example.com", APIKeyFile: emptyPath},
+			err:   "is empty",
+		},
+		"MissingFile": {
+			flags: GlobalFlags{Endpoint: "https://example.com", APIKeyFile: filepath.Join(dir, "missing")},
+			err:   "reading api key file",
+		},
+		"FailingCommand": {
+			flags: GlobalFlags{Endpoint: "https://example.com", APIKeyCommand: "exit 1"},
+			err:   "running api key command",
+		},
+		"CommandWithoutOutput": {
+			flags: GlobalFlags{Endpoint: "https://example.com", APIKeyCommand: "true"},
+			err:   "api key command printed nothing",
+		},
+		"FileAndCommand": {
+			flags: GlobalFlags{
+				Endpoint:      "https://example.com",
+				APIKeyFile:    keyPath,
+				APIKeyCommand: "echo key",
+			},
+			err: `only one credential can be used, but found "--api-key-file", "--api-key-command"`,
+		},
+	}
+
+	for name, testCase := range tests {
+		tc := testCase
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			logger := log.New()
+			ctx := logger.WithContext(context.Background())
+
+			tc.flags.Config = configPath
+
+			err := tc.flags.Resolve(ctx)
+			if tc.err != "" {
+				require.ErrorContains(t, err, tc.err)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, tc.flags.APIKey)
+			require.Empty(t, tc.flags.APIKeyFile)
+			require.Empty(t, tc.flags.APIKeyCommand)
+		})
+	}
+}
+
+func TestGlobalFlagsResolve_PasswordFile(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+
+	passwordPath := filepath.Join(dir, "password")
+	require.NoError(t, os.WriteFile(passwordPath, []byte(" secret \n"), 0o600))
+
+	emptyPath := filepath.Join(dir, "empty")
+	require.NoError(t, os.WriteFile(emptyPath, []byte("\n"), 0o600))
+
+	configPath := filepath.Join(dir, "config.yml")
+	require.NoError(t, os.WriteFile(configPath, []byte(`profiles:
+  password:
+    endpoint: https://example.com
+    username: admin
+    password_file: password
+`), 0o600))
+
+	tests := map[string]struct {
+		flags    GlobalFlags
+		expected string
+		err      string
+	}{
+		"File": {
+			flags: GlobalFlags{
+				Endpoint: "https://example.com", Username: "admin", PasswordFile: passwordPath,
+			},
+			expected: " secret ",
+		},
+		"ProfileFileRelativeToConfig": {
+			flags:    GlobalFlags{Profile: "password"},
+			expected: " secret ",
+		},
+		"EmptyFile": {
+			flags: GlobalFlags{
+				Endpoint: "https://example.com", Username: "admin", PasswordFile: emptyPath,
+			},
+			err: "is empty",
+		},
+		"MissingFile": {
+			flags: GlobalFlags{
+				Endpoint:     "https://example.com",
+				Username:     "admin",
+				PasswordFile: filepath.Join(dir, "missing"),
+			},
+			err: "reading password file",
+		},
+		"WithoutUsername": {
+			flags: GlobalFlags{Endpoint: "https://example.com", PasswordFile: passwordPath},
+			err:   `"--username" and "--password" must be used together`,
+		},
+		"PasswordAndFile": {
+			flags: GlobalFlags{
+				Endpoint:     "https://example.com",
+				Username:     "admin",
+				Password:     "secret",
+				PasswordFile: passwordPath,
+			},
+			err: `"--password" and "--password-file" cannot be used together`,
+		},
+		"APIKeyAndFile": {
+			flags: GlobalFlags{
+				Endpoint:     "https://example.com",
+				APIKey:       "key",
+				Username:     "admin",
+				PasswordFile: passwordPath,
+			},
+			err: `only one credential can be used, but found "--api-key", "--username" and "--password"`,
+		},
+	}
+
+	for name, testCase := range tests {
+		tc := testCase
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			logger := log.New()
+			ctx := logger.WithContext(context.Background())
+
+			tc.flags.Config = configPath
+
+			err := tc.flags.Resolve(ctx)
+			if tc.err != "" {
+				require.ErrorContains(t, err, tc.err)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, "admin", tc.flags.Username)
+			require.Equal(t, tc.expected, tc.flags.Password)
+			require.Empty(t, tc.flags.PasswordFile)
+			require.True(t, tc.flags.UsesBasicAuth())
+		})
+	}
+}
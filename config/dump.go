@@ -27,3 +27,14 @@ func (d *DumpFlags) Flags(ctx context.Context) []cli.Flag {
 		},
 	}
 }
+
+// Resolve sets the path from the profile if it was not set. The path is optional.
+func (d *DumpFlags) Resolve(ctx context.Context, cfg *GlobalFlags) error {
+	if d.Path != "" || cfg.profile.DumpPath == "" {
+		return nil
+	}
+
+	d.Path = cfg.profile.DumpPath
+
+	return kitchensink.ValidateFileExtension(ctx, d.Path, []string{".yaml", ".yml"}) //nolint:wrapcheck
+}
@@ -1,14 +1,48 @@
 package config
 
 import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
 	"github.com/urfave/cli/v2"
 )
 
 // GlobalFlags holds the configuration for the CLI.
 type GlobalFlags struct {
-	APIKey   string
-	Endpoint string
-	Version  string
+	APIKey          string
+	APIKeyCommand   string
+	APIKeyFile      string
+	Burst           int
+	CACert          string
+	ClientCert      string
+	ClientKey       string
+	Config          string
+	Endpoint        string
+	Headers         map[string]string
+	Insecure        bool
+	Password        string
+	PasswordFile    string
+	Profile         string
+	Proxy           string
+	Rate            float64
+	Record          string
+	Replay          string
+	RequestTimeout  time.Duration
+	Retries         int
+	RetryBackoff    time.Duration
+	RetryMaxBackoff time.Duration
+	Timeout         time.Duration
+	Username        string
+	Version         string
+
+	// insecureSet is true when "--insecure-skip-verify" is set by a flag or env var, so that false
+	// overrides the profile.
+	insecureSet bool
+
+	// profile is the profile selected from the config file, set by Resolve.
+	profile Profile
 }
 
 // New is a convenience function for creating a new Config.
@@ -20,22 +54,269 @@ func New(v string) *GlobalFlags {
 
 // Flags returns the flags for the CLI.
 func (c *GlobalFlags) Flags() []cli.Flag {
-	return []cli.Flag{
+	flags := []cli.Flag{
 		&cli.StringFlag{
 			Name:        "api-key",
-			Usage:       "Miniflux API key. (required)",
+			Usage:       "Miniflux API key. (required, unless another credential is used or set by the profile)",
 			EnvVars:     []string{"MINIFLUX_SYNC_API_KEY"},
 			Destination: &c.APIKey,
 			Aliases:     []string{"a"},
-			Required:    true,
+		},
+		&cli.StringFlag{
+			Name:        "api-key-file",
+			Usage:       "Path to a file holding the Miniflux API key, such as a Docker or Kubernetes secret.",
+			EnvVars:     []string{"MINIFLUX_SYNC_API_KEY_FILE"},
+			Destination: &c.APIKeyFile,
+		},
+		&cli.StringFlag{
+			Name:        "api-key-command",
+			Usage:       "Command which prints the Miniflux API key, e.g. \"pass show miniflux\".",
+			EnvVars:     []string{"MINIFLUX_SYNC_API_KEY_COMMAND"},
+			Destination: &c.APIKeyCommand,
+		},
+		&cli.StringFlag{
+			Name:        "config",
+			Usage:       "Path to the config file holding profiles.",
+			EnvVars:     []string{"MINIFLUX_SYNC_CONFIG"},
+			Destination: &c.Config,
+			DefaultText: DefaultConfigPath(),
 		},
 		&cli.StringFlag{
 			Name:        "endpoint",
-			Usage:       "Miniflux API endpoint. (required)",
+			Usage:       "Miniflux API endpoint. (required, unless set by the profile)",
 			EnvVars:     []string{"MINIFLUX_SYNC_ENDPOINT"},
 			Destination: &c.Endpoint,
 			Aliases:     []string{"e"},
-			Required:    true,
 		},
+		&cli.StringFlag{
+			Name:        "username",
+			Usage:       "Miniflux username, for basic auth instead of an API key. Requires \"--password\".",
+			EnvVars:     []string{"MINIFLUX_SYNC_USERNAME"},
+			Destination: &c.Username,
+			Aliases:     []string{"u"},
+		},
+		&cli.StringFlag{
+			Name:        "password",
+			Usage:       "Miniflux password, for basic auth instead of an API key. Requires \"--username\".",
+			EnvVars:     []string{"MINIFLUX_SYNC_PASSWORD"},
+			Destination: &c.Password,
+		},
+		&cli.StringFlag{
+			Name:        "password-file",
+			Usage:       "Path to a file holding the Miniflux password, such as a Docker or Kubernetes secret.",
+			EnvVars:     []string{"MINIFLUX_SYNC_PASSWORD_FILE"},
+			Destination: &c.PasswordFile,
+		},
+		&cli.StringFlag{
+			Name:        "profile",
+			Usage:       "Name of the profile to use from the config file. (default: default_profile in the file)",
+			EnvVars:     []string{"MINIFLUX_SYNC_PROFILE"},
+			Destination: &c.Profile,
+		},
+		&cli.Float64Flag{
+			Name:        "rate",
+			Usage:       "Maximum number of Miniflux API requests per second, or 0 for no limit.",
+			EnvVars:     []string{"MINIFLUX_SYNC_RATE"},
+			Destination: &c.Rate,
+			Value:       0,
+			Action: func(_ *cli.Context, f float64) error {
+				if f < 0 {
+					return errors.New("rate must not be negative")
+				}
+
+				return nil
+			},
+		},
+		&cli.IntFlag{
+			Name:        "burst",
+			Usage:       "Maximum number of Miniflux API requests sent at once when rate limited.",
+			EnvVars:     []string{"MINIFLUX_SYNC_BURST"},
+			Destination: &c.Burst,
+			Value:       1,
+			Action: func(_ *cli.Context, i int) error {
+				if i < 1 {
+					return errors.New("burst must be at least 1")
+				}
+
+				return nil
+			},
+		},
+		&cli.StringFlag{
+			Name:        "record",
+			Usage:       "Record every Miniflux API request and response, redacted, to a JSON Lines file, e.g. session.jsonl.",
+			EnvVars:     []string{"MINIFLUX_SYNC_RECORD"},
+			Destination: &c.Record,
+		},
+		&cli.StringFlag{
+			Name:        "replay",
+			Usage:       "Answer Miniflux API requests from the JSON Lines file written by \"--record\", offline.",
+			EnvVars:     []string{"MINIFLUX_SYNC_REPLAY"},
+			Destination: &c.Replay,
+		},
+		&cli.IntFlag{
+			Name:        "retries",
+			Usage:       "Maximum number of retries for transient Miniflux API failures.",
+			EnvVars:     []string{"MINIFLUX_SYNC_RETRIES"},
+			Destination: &c.Retries,
+			Value:       3, //nolint:mnd
+			Action: func(_ *cli.Context, i int) error {
+				if i < 0 {
+					return errors.New("retries must not be negative")
+				}
+
+				return nil
+			},
+		},
+		&cli.DurationFlag{
+			Name:        "retry-backoff",
+			Usage:       "Delay before the first retry, which doubles after each attempt.",
+			EnvVars:     []string{"MINIFLUX_SYNC_RETRY_BACKOFF"},
+			Destination: &c.RetryBackoff,
+			Value:       time.Second,
+		},
+		&cli.DurationFlag{
+			Name:        "retry-max-backoff",
+			Usage:       "Maximum delay between retries.",
+			EnvVars:     []string{"MINIFLUX_SYNC_RETRY_MAX_BACKOFF"},
+			Destination: &c.RetryMaxBackoff,
+			Value:       30 * time.Second, //nolint:mnd
+		},
+		&cli.DurationFlag{
+			Name:        "timeout",
+			Usage:       "Maximum duration of the whole run, or of each sync with \"--watch\", or 0 for no limit.",
+			EnvVars:     []string{"MINIFLUX_SYNC_TIMEOUT"},
+			Destination: &c.Timeout,
+			Value:       0,
+			Action:      nonNegativeDuration("timeout"),
+		},
+		&cli.DurationFlag{
+			Name:        "request-timeout",
+			Usage:       "Maximum duration of each Miniflux API request, or 0 for the client default of 80s.",
+			EnvVars:     []string{"MINIFLUX_SYNC_REQUEST_TIMEOUT"},
+			Destination: &c.RequestTimeout,
+			Value:       0,
+			Action:      nonNegativeDuration("request timeout"),
+		},
+	}
+
+	return append(flags, c.transportFlags()...)
+}
+
+// Resolve fills the settings which were not set by flags or env vars from the selected profile,
+// then checks that the required settings are set.
+func (c *GlobalFlags) Resolve(ctx context.Context) error {
+	path := c.Config
+	if path == "" {
+		path = DefaultConfigPath()
+	}
+
+	if path != "" {
+		profile, err := LoadProfile(ctx, path, c.Profile, c.Config != "")
+		if err != nil {
+			return err
+		}
+
+		if profile != nil {
+			c.profile = *profile
+		}
+	} else if c.Profile != "" {
+		return errors.New(`cannot find the config file, set "--config"`)
+	}
+
+	if c.Endpoint == "" {
+		c.Endpoint = c.profile.Endpoint
+	}
+
+	if err := c.resolveTransport(); err != nil {
+		return err
+	}
+
+	// Credentials from flags or env vars replace those of the profile, rather than being combined.
+	if len(c.credentialMethods()) == 0 {
+		c.APIKey = c.profile.APIKey
+		c.APIKeyFile = c.profile.APIKeyFile
+		c.APIKeyCommand = c.profile.APIKeyCommand
+		c.Username = c.profile.Username
+		c.Password = c.profile.Password
+		c.PasswordFile = c.profile.PasswordFile
+	}
+
+	if c.Endpoint == "" {
+		return missingSettingError("endpoint", "MINIFLUX_SYNC_ENDPOINT")
+	}
+
+	if err := c.validateCredentials(); err != nil {
+		return err
+	}
+
+	return c.loadCredentials(ctx)
+}
+
+// UsesBasicAuth reports whether the Miniflux API is authenticated with a username and password,
+// rather than an API key.
+func (c *GlobalFlags) UsesBasicAuth() bool {
+	return c.APIKey == "" && c.Username != ""
+}
+
+// credentialMethods returns the flags of each method of authentication which is set.
+func (c *GlobalFlags) credentialMethods() []string {
+	methods := []string{}
+
+	if c.APIKey != "" {
+		methods = append(methods, `"--api-key"`)
+	}
+
+	if c.APIKeyFile != "" {
+		methods = append(methods, `"--api-key-file"`)
+	}
+
+	if c.APIKeyCommand != "" {
+		methods = append(methods, `"--api-key-command"`)
+	}
+
+	if c.Username != "" || c.Password != "" || c.PasswordFile != "" {
+		methods = append(methods, `"--username" and "--password"`)
+	}
+
+	return methods
+}
+
+// validateCredentials checks that exactly one method of authentication is set.
+func (c *GlobalFlags) validateCredentials() error {
+	methods := c.credentialMethods()
+
+	switch {
+	case len(methods) > 1:
+		return errors.Errorf("only one credential can be used, but found %s", strings.Join(methods, ", "))
+
+	case len(methods) == 0:
+		return errors.New(
+			`a credential is required, set "--api-key", "--api-key-file", "--api-key-command", ` +
+				`or "--username" and "--password", their "MINIFLUX_SYNC_" env vars, or a profile`,
+		)
+
+	case c.Password != "" && c.PasswordFile != "":
+		return errors.New(`"--password" and "--password-file" cannot be used together`)
+
+	case (c.Username != "") != (c.Password != "" || c.PasswordFile != ""):
+		return errors.New(`"--username" and "--password" must be used together`)
+	}
+
+	return nil
+}
+
+// missingSettingError returns the error for a required setting which is not set.
+func missingSettingError(flag string, envVar string) error {
+	return errors.Errorf(`"--%s" is required, set it with the flag, "%s" or a profile`, flag, envVar)
+}
+
+// nonNegativeDuration returns a flag action which checks that a duration is not negative.
+func nonNegativeDuration(name string) func(*cli.Context, time.Duration) error {
+	return func(_ *cli.Context, d time.Duration) error {
+		if d < 0 {
+			return errors.Errorf("%s must not be negative", name)
+		}
+
+		return nil
 	}
 }
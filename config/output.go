@@ -0,0 +1,43 @@
+package config
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/urfave/cli/v2"
+)
+
+const (
+	// OutputText renders the plan as log lines.
+	OutputText = "text"
+
+	// OutputJSON renders the plan as a single JSON document on stdout.
+	OutputJSON = "json"
+
+	// OutputMarkdown renders the plan as Markdown tables on stdout, and appends them to the GitHub
+	// job summary when GITHUB_STEP_SUMMARY is set.
+	OutputMarkdown = "markdown"
+)
+
+// outputFormats is the list of supported values for the output flag.
+var outputFormats = []string{OutputText, OutputJSON, OutputMarkdown}
+
+// outputFlag returns the flag used to select how a plan is rendered.
+func outputFlag(destination *string) *cli.StringFlag {
+	return &cli.StringFlag{
+		Name:        "output",
+		Usage:       fmt.Sprintf("Format for the plan output: %s.", strings.Join(outputFormats, ", ")),
+		EnvVars:     []string{"MINIFLUX_SYNC_OUTPUT"},
+		Destination: destination,
+		Value:       OutputText,
+		Action: func(_ *cli.Context, s string) error {
+			if !slices.Contains(outputFormats, s) {
+				return errors.Errorf(`unsupported output format: "%s"`, s)
+			}
+
+			return nil
+		},
+	}
+}
@@ -0,0 +1,86 @@
+package config
+
+import (
+	"context"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/revett/miniflux-sync/kitchensink"
+	"github.com/urfave/cli/v2"
+)
+
+// PlanFlags holds the flags for the plan command.
+type PlanFlags struct {
+	Out                string
+	Output             string
+	Path               string
+	UnsupportedOptions string
+}
+
+// Flags returns the flags for the plan command.
+func (p *PlanFlags) Flags(ctx context.Context) []cli.Flag {
+	return []cli.Flag{
+		&cli.StringFlag{
+			Name:        "out",
+			Usage:       "Path to file for the saved plan, which can be used with apply. (optional)",
+			EnvVars:     []string{"MINIFLUX_SYNC_PLAN_OUT"},
+			Destination: &p.Out,
+			Aliases:     []string{"o"},
+			Action: func(_ *cli.Context, s string) error {
+				return kitchensink.ValidateFileExtension(ctx, s, []string{".json"})
+			},
+		},
+		outputFlag(&p.Output),
+		&cli.StringFlag{
+			Name:        "path",
+			Usage:       "Path to file for imported data. (required, unless set by the profile)",
+			EnvVars:     []string{"MINIFLUX_SYNC_PATH"},
+			Destination: &p.Path,
+			Aliases:     []string{"p"},
+			Action: func(_ *cli.Context, path string) error {
+				return validateInputFile(ctx, path, []string{".yaml", ".yml"})
+			},
+		},
+		unsupportedOptionsFlag(&p.UnsupportedOptions),
+	}
+}
+
+// Resolve sets the path from the profile if it was not set, and checks that it is set.
+func (p *PlanFlags) Resolve(ctx context.Context, cfg *GlobalFlags) error {
+	return resolveInputPath(ctx, &p.Path, cfg)
+}
+
+// ApplyFlags holds the flags and arguments for the apply command.
+type ApplyFlags struct {
+	Concurrency       int
+	KeepGoing         bool
+	PlanPath          string
+	RollbackOnFailure bool
+}
+
+// Flags returns the flags for the apply command.
+func (a *ApplyFlags) Flags() []cli.Flag {
+	return []cli.Flag{
+		concurrencyFlag(&a.Concurrency),
+		keepGoingFlag(&a.KeepGoing),
+		rollbackOnFailureFlag(&a.RollbackOnFailure),
+	}
+}
+
+// Parse reads the plan path from the command arguments. Flags are only parsed before the plan path,
+// so any given after it are rejected, rather than ignored.
+func (a *ApplyFlags) Parse(ctx context.Context, c *cli.Context) error {
+	for _, arg := range c.Args().Tail() {
+		if strings.HasPrefix(arg, "-") {
+			return errors.Errorf(`flags must come before the plan path, but found "%s" after it`, arg)
+		}
+	}
+
+	if c.NArg() != 1 {
+		return errors.New("expected exactly one argument: path to plan file")
+	}
+
+	a.PlanPath = c.Args().First()
+
+	return validateInputFile(ctx, a.PlanPath, []string{".json"})
+}
@@ -0,0 +1,75 @@
+package config
+
+import (
+	"context"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/revett/miniflux-sync/log"
+	"github.com/stretchr/testify/require"
+	"github.com/urfave/cli/v2"
+)
+
+func TestApplyFlagsParse(t *testing.T) {
+	t.Parallel()
+
+	planPath := filepath.Join(t.TempDir(), "plan.json")
+	require.NoError(t, os.WriteFile(planPath, []byte("{}\n"), 0o600))
+
+	tests := map[string]struct {
+		args     []string
+		expected string
+		err      string
+	}{
+		"Path": {
+			args:     []string{planPath},
+			expected: planPath,
+		},
+		"FlagsBeforePath": {
+			args:     []string{"--keep-going", planPath},
+			expected: planPath,
+		},
+		"FlagAfterPath": {
+			args: []string{planPath, "--keep-going"},
+			err:  `flags must come before the plan path, but found "--keep-going" after it`,
+		},
+		"MissingPath": {
+			args: []string{},
+			err:  "expected exactly one argument",
+		},
+		"ExtraArgument": {
+			args: []string{planPath, planPath},
+			err:  "expected exactly one argument",
+		},
+	}
+
+	for name, testCase := range tests {
+		tc := testCase
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			flags := &ApplyFlags{}
+
+			set := flag.NewFlagSet("apply", flag.ContinueOnError)
+			for _, f := range flags.Flags() {
+				require.NoError(t, f.Apply(set))
+			}
+			require.NoError(t, set.Parse(tc.args))
+
+			logger := log.New()
+			ctx := logger.WithContext(context.Background())
+
+			err := flags.Parse(ctx, cli.NewContext(cli.NewApp(), set, nil))
+			if tc.err != "" {
+				require.ErrorContains(t, err, tc.err)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, flags.PlanPath)
+		})
+	}
+}
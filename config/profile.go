@@ -0,0 +1,150 @@
+package config
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/revett/miniflux-sync/log"
+	"gopkg.in/yaml.v2"
+)
+
+// Profile holds the settings for one Miniflux instance, read from the config file. Flags and env
+// vars override the values of the selected profile.
+type Profile struct {
+	Endpoint      string            `yaml:"endpoint"`
+	APIKey        string            `yaml:"api_key"`
+	APIKeyFile    string            `yaml:"api_key_file"`
+	APIKeyCommand string            `yaml:"api_key_command"`
+	Username      string            `yaml:"username"`
+	Password      string            `yaml:"password"`
+	PasswordFile  string            `yaml:"password_file"`
+	CACert        string            `yaml:"ca_cert"`
+	ClientCert    string            `yaml:"client_cert"`
+	ClientKey     string            `yaml:"client_key"`
+	Insecure      bool              `yaml:"insecure_skip_verify"`
+	Proxy         string            `yaml:"proxy"`
+	Headers       map[string]string `yaml:"headers"`
+	Path          string            `yaml:"path"`
+	DumpPath      string            `yaml:"dump_path"`
+}
+
+// File is the config file, holding named profiles.
+type File struct {
+	// DefaultProfile is the profile used when "--profile" is not set.
+	DefaultProfile string             `yaml:"default_profile"`
+	Profiles       map[string]Profile `yaml:"profiles"`
+}
+
+// DefaultConfigPath returns the default path of the config file, in the XDG config directory. It
+// returns an empty string if the home directory is unknown.
+func DefaultConfigPath() string {
+	dir := os.Getenv("XDG_CONFIG_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return ""
+		}
+
+		dir = filepath.Join(home, ".config")
+	}
+
+	return filepath.Join(dir, "miniflux-sync", "config.yml")
+}
+
+// LoadProfile reads the config file at path, and returns the profile with the given name, or the
+// default profile if name is empty. A nil profile is returned if no profile is selected. A missing
+// config file is only an error if required is true, or a profile was named.
+func LoadProfile(ctx context.Context, path string, name string, required bool) (*Profile, error) {
+	dat, err := os.ReadFile(path)
+	if os.IsNotExist(err) && !required && name == "" {
+		return nil, nil //nolint:nilnil
+	}
+
+	if err != nil {
+		return nil, errors.Wrap(err, "reading config file")
+	}
+
+	var file File
+	if err := yaml.UnmarshalStrict(dat, &file); err != nil {
+		return nil, errors.Wrap(err, "parsing config file")
+	}
+
+	if name == "" {
+		name = file.DefaultProfile
+	}
+
+	if name == "" {
+		return nil, nil //nolint:nilnil
+	}
+
+	profile, ok := file.Profiles[name]
+	if !ok {
+		names := make([]string, 0, len(file.Profiles))
+		for n := range file.Profiles {
+			names = append(names, n)
+		}
+
+		slices.Sort(names)
+
+		return nil, errors.Errorf(
+			`profile "%s" not found in config file, available: %s`, name, strings.Join(names, ", "),
+		)
+	}
+
+	// Headers can hold credentials too, such as Cloudflare Access service tokens.
+	if profile.APIKey != "" || profile.Password != "" || len(profile.Headers) > 0 {
+		warnIfReadableByOthers(ctx, path)
+	}
+
+	dir := filepath.Dir(path)
+	profile.APIKeyFile = resolveProfilePath(dir, profile.APIKeyFile)
+	profile.PasswordFile = resolveProfilePath(dir, profile.PasswordFile)
+	profile.CACert = resolveProfilePath(dir, profile.CACert)
+	profile.ClientCert = resolveProfilePath(dir, profile.ClientCert)
+	profile.ClientKey = resolveProfilePath(dir, profile.ClientKey)
+	profile.Path = resolveProfilePath(dir, profile.Path)
+	profile.DumpPath = resolveProfilePath(dir, profile.DumpPath)
+
+	log.Info(ctx, "using profile from config file", log.Metadata{
+		"profile": name,
+		"path":    path,
+	})
+
+	return &profile, nil
+}
+
+// resolveProfilePath expands a leading "~/" to the home directory, and resolves relative paths
+// against the directory of the config file.
+func resolveProfilePath(dir string, path string) string {
+	if path == "" || filepath.IsAbs(path) {
+		return path
+	}
+
+	if rest, ok := strings.CutPrefix(path, "~/"); ok {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, rest)
+		}
+	}
+
+	return filepath.Join(dir, path)
+}
+
+// warnIfReadableByOthers warns when the config file holds credentials, but can be read by other
+// users.
+func warnIfReadableByOthers(ctx context.Context, path string) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return
+	}
+
+	if info.Mode().Perm()&0o077 != 0 {
+		log.Warn(ctx, "config file contains credentials, but is readable by other users", log.Metadata{
+			"path": path,
+			"mode": info.Mode().Perm().String(),
+		})
+	}
+}
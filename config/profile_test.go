@@ -0,0 +1,220 @@
+package config
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/revett/miniflux-sync/log"
+	"github.com/stretchr/testify/require"
+)
+
+const testConfigFile = `default_profile: personal
+profiles:
+  personal:
+    endpoint: https://personal.example.com
+    api_key: personal-key
+    path: feeds/personal.yml
+    dump_path: /tmp/personal-dump.yml
+  team:
+    endpoint: https://team.example.com
+    api_key: team-key
+  legacy:
+    endpoint: https://legacy.example.com
+    username: admin
+    password: secret
+`
+
+func writeConfigFile(t *testing.T, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "config.yml")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+
+	return path
+}
+
+func TestLoadProfile(t *testing.T) {
+	t.Parallel()
+
+	path := writeConfigFile(t, testConfigFile)
+	dir := filepath.Dir(path)
+
+	tests := map[string]struct {
+		path     string
+		name     string
+		required bool
+		expected *Profile
+		err      string
+	}{
+		"DefaultProfile": {
+			path: path,
+			expected: &Profile{
+				Endpoint: "https://personal.example.com",
+				APIKey:   "personal-key",
+				Path:     filepath.Join(dir, "feeds", "personal.yml"),
+				DumpPath: "/tmp/personal-dump.yml",
+			},
+		},
+		"NamedProfile": {
+			path: path,
+			name: "team",
+			expected: &Profile{
+				Endpoint: "https://team.example.com",
+				APIKey:   "team-key",
+			},
+		},
+		"UnknownProfile": {
+			path: path,
+			name: "work",
+			err:  `profile "work" not found in config file, available: legacy, personal, team`,
+		},
+		"MissingFile": {
+			path:     filepath.Join(dir, "missing.yml"),
+			expected: nil,
+		},
+		"MissingFileRequired": {
+			path:     filepath.Join(dir, "missing.yml"),
+			required: true,
+			err:      "reading config file",
+		},
+		"MissingFileWithProfile": {
+			path: filepath.Join(dir, "missing.yml"),
+			name: "team",
+			err:  "reading config file",
+		},
+	}
+
+	for name, testCase := range tests {
+		tc := testCase
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			logger := log.New()
+			ctx := logger.WithContext(context.Background())
+
+			profile, err := LoadProfile(ctx, tc.path, tc.name, tc.required)
+			if tc.err != "" {
+				require.ErrorContains(t, err, tc.err)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, profile)
+		})
+	}
+}
+
+func TestLoadProfile_NoDefault(t *testing.T) {
+	t.Parallel()
+
+	path := writeConfigFile(t, "profiles:\n  team:\n    endpoint: https://team.example.com\n")
+
+	logger := log.New()
+	ctx := logger.WithContext(context.Background())
+
+	profile, err := LoadProfile(ctx, path, "", true)
+	require.NoError(t, err)
+	require.Nil(t, profile)
+}
+
+func TestLoadProfile_UnknownField(t *testing.T) {
+	t.Parallel()
+
+	path := writeConfigFile(t, "profiles:\n  team:\n    endpont: https://team.example.com\n")
+
+	logger := log.New()
+	ctx := logger.WithContext(context.Background())
+
+	_, err := LoadProfile(ctx, path, "team", true)
+	require.ErrorContains(t, err, "parsing config file")
+}
+
+func TestGlobalFlagsResolve(t *testing.T) {
+	t.Parallel()
+
+	path := writeConfigFile(t, testConfigFile)
+
+	tests := map[string]struct {
+		flags    GlobalFlags
+		expected GlobalFlags
+		err      string
+	}{
+		"FromProfile": {
+			flags: GlobalFlags{Config: path, Profile: "team"},
+			expected: GlobalFlags{
+				Config:   path,
+				Profile:  "team",
+				Endpoint: "https://team.example.com",
+				APIKey:   "team-key",
+			},
+		},
+		"FlagsOverrideProfile": {
+			flags: GlobalFlags{Config: path, Endpoint: "https://other.example.com"},
+			expected: GlobalFlags{
+				Config:   path,
+				Endpoint: "https://other.example.com",
+				APIKey:   "personal-key",
+			},
+		},
+		"MissingEndpoint": {
+			flags: GlobalFlags{Config: writeConfigFile(t, "profiles: {}\n"), APIKey: "key"},
+			err:   `"--endpoint" is required`,
+		},
+		"MissingCredentials": {
+			flags: GlobalFlags{Config: writeConfigFile(t, "profiles: {}\n"), Endpoint: "https://x"},
+			err:   `a credential is required`,
+		},
+		"BasicAuthOverridesProfile": {
+			flags: GlobalFlags{Config: path, Username: "admin", Password: "secret"},
+			expected: GlobalFlags{
+				Config:   path,
+				Endpoint: "https://personal.example.com",
+				Username: "admin",
+				Password: "secret",
+			},
+		},
+		"BasicAuthFromProfile": {
+			flags: GlobalFlags{Config: path, Profile: "legacy"},
+			expected: GlobalFlags{
+				Config:   path,
+				Profile:  "legacy",
+				Endpoint: "https://legacy.example.com",
+				Username: "admin",
+				Password: "secret",
+			},
+		},
+		"BothMethods": {
+			flags: GlobalFlags{Config: path, APIKey: "key", Username: "admin", Password: "secret"},
+			err:   `only one credential can be used, but found "--api-key", "--username" and "--password"`,
+		},
+		"UsernameWithoutPassword": {
+			flags: GlobalFlags{Config: path, Username: "admin"},
+			err:   `"--username" and "--password" must be used together`,
+		},
+	}
+
+	for name, testCase := range tests {
+		tc := testCase
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			logger := log.New()
+			ctx := logger.WithContext(context.Background())
+
+			err := tc.flags.Resolve(ctx)
+			if tc.err != "" {
+				require.ErrorContains(t, err, tc.err)
+				return
+			}
+
+			require.NoError(t, err)
+
+			tc.flags.profile = Profile{}
+			require.Equal(t, tc.expected, tc.flags)
+		})
+	}
+}
@@ -3,6 +3,7 @@ package config
 import (
 	"context"
 	"os"
+	"time"
 
 	"github.com/pkg/errors"
 	"github.com/revett/miniflux-sync/kitchensink"
@@ -11,13 +12,25 @@ import (
 
 // SyncFlags holds the flags for the sync command.
 type SyncFlags struct {
-	DryRun bool
-	Path   string
+	Concurrency        int
+	DryRun             bool
+	Journal            bool
+	JournalFile        string
+	KeepGoing          bool
+	Output             string
+	Path               string
+	Resume             bool
+	RollbackOnFailure  bool
+	Targets            []string
+	UnsupportedOptions string
+	Watch              bool
+	WatchDebounce      time.Duration
 }
 
 // Flags returns the flags for the sync command.
 func (s *SyncFlags) Flags(ctx context.Context) []cli.Flag {
 	return []cli.Flag{
+		concurrencyFlag(&s.Concurrency),
 		&cli.BoolFlag{
 			Name:        "dry-run",
 			Usage:       "Perform a dry run without making any changes.",
@@ -26,31 +39,171 @@ func (s *SyncFlags) Flags(ctx context.Context) []cli.Flag {
 			Aliases:     []string{"d"},
 			Value:       false,
 		},
+		&cli.BoolFlag{
+			Name:        "journal",
+			Usage:       `Record progress so an interrupted sync can be finished by "--resume". Disable with "--journal=false".`,
+			EnvVars:     []string{"MINIFLUX_SYNC_JOURNAL"},
+			Destination: &s.Journal,
+			Value:       true,
+		},
+		&cli.StringFlag{
+			Name:        "journal-file",
+			Usage:       `Path to the journal. (default: path with ".journal" suffix)`,
+			EnvVars:     []string{"MINIFLUX_SYNC_JOURNAL_FILE"},
+			Destination: &s.JournalFile,
+		},
+		keepGoingFlag(&s.KeepGoing),
+		outputFlag(&s.Output),
 		&cli.StringFlag{
 			Name:        "path",
-			Usage:       "Path to file for imported data. (required)",
+			Usage:       "Path to file for imported data. (required, unless set by the profile)",
 			EnvVars:     []string{"MINIFLUX_SYNC_PATH"},
 			Destination: &s.Path,
 			Aliases:     []string{"p"},
-			Required:    true,
 			Action: func(_ *cli.Context, path string) error {
-				if err := kitchensink.ValidateFileExtension(
-					ctx, path, []string{".yaml", ".yml"},
-				); err != nil {
-					return errors.Wrap(err, "validating file extension")
-				}
+				return validateInputFile(ctx, path, []string{".yaml", ".yml"})
+			},
+		},
+		rollbackOnFailureFlag(&s.RollbackOnFailure),
+		&cli.BoolFlag{
+			Name:        "resume",
+			Usage:       "Finish an interrupted sync using its journal, skipping actions already applied.",
+			EnvVars:     []string{"MINIFLUX_SYNC_RESUME"},
+			Destination: &s.Resume,
+			Value:       false,
+		},
+		&cli.StringSliceFlag{
+			Name:    "target",
+			Usage:   "Name of a profile to sync to, which can be repeated to sync to several instances.",
+			EnvVars: []string{"MINIFLUX_SYNC_TARGETS"},
+			Aliases: []string{"t"},
+			Action: func(_ *cli.Context, targets []string) error {
+				s.Targets = targets
+				return nil
+			},
+		},
+		unsupportedOptionsFlag(&s.UnsupportedOptions),
+		&cli.BoolFlag{
+			Name:        "watch",
+			Usage:       "Keep running, and sync again whenever the data file changes.",
+			EnvVars:     []string{"MINIFLUX_SYNC_WATCH"},
+			Destination: &s.Watch,
+			Aliases:     []string{"w"},
+			Value:       false,
+		},
+		&cli.DurationFlag{
+			Name:        "watch-debounce",
+			Usage:       "Time to wait for further changes to the data file before syncing, with \"--watch\".",
+			EnvVars:     []string{"MINIFLUX_SYNC_WATCH_DEBOUNCE"},
+			Destination: &s.WatchDebounce,
+			Value:       time.Second,
+			Action:      nonNegativeDuration("watch debounce"),
+		},
+	}
+}
+
+// Resolve sets the path from the profile if it was not set, and checks that it is set.
+func (s *SyncFlags) Resolve(ctx context.Context, cfg *GlobalFlags) error {
+	return resolveInputPath(ctx, &s.Path, cfg)
+}
 
-				info, err := os.Stat(path)
-				if os.IsNotExist(err) {
-					return errors.Wrap(err, "file does not exist")
-				}
+// Journaled returns true if the progress of a sync should be recorded in a journal.
+func (s *SyncFlags) Journaled() bool {
+	return s.Journal
+}
 
-				if info.IsDir() {
-					return errors.New("file is a directory")
-				}
+// JournalPath returns the path of the journal file, which defaults to the path of the data file
+// with a ".journal" suffix.
+func (s *SyncFlags) JournalPath() string {
+	if s.JournalFile != "" {
+		return s.JournalFile
+	}
 
-				return nil
-			},
+	return s.Path + ".journal"
+}
+
+// concurrencyFlag returns the flag used to set how many actions are performed at once.
+func concurrencyFlag(destination *int) *cli.IntFlag {
+	return &cli.IntFlag{
+		Name:        "concurrency",
+		Usage:       "Maximum number of independent actions to perform at once.",
+		EnvVars:     []string{"MINIFLUX_SYNC_CONCURRENCY"},
+		Destination: destination,
+		Aliases:     []string{"c"},
+		Value:       1,
+		Action: func(_ *cli.Context, i int) error {
+			if i < 1 {
+				return errors.New("concurrency must be at least 1")
+			}
+
+			return nil
 		},
 	}
 }
+
+// keepGoingFlag returns the flag used to continue after an action fails.
+func keepGoingFlag(destination *bool) *cli.BoolFlag {
+	return &cli.BoolFlag{
+		Name:        "keep-going",
+		Usage:       "Continue after an action fails, skipping only the actions that depend on it.",
+		EnvVars:     []string{"MINIFLUX_SYNC_KEEP_GOING"},
+		Destination: destination,
+		Aliases:     []string{"k"},
+		Value:       false,
+	}
+}
+
+// rollbackOnFailureFlag returns the flag used to undo the actions already performed when an action
+// fails.
+func rollbackOnFailureFlag(destination *bool) *cli.BoolFlag {
+	return &cli.BoolFlag{
+		Name:        "rollback-on-failure",
+		Usage:       "Undo the actions already performed if an action fails.",
+		EnvVars:     []string{"MINIFLUX_SYNC_ROLLBACK_ON_FAILURE"},
+		Destination: destination,
+		Value:       false,
+		Action: func(c *cli.Context, rollback bool) error {
+			// Carrying on after a failure, only to undo everything at the end, is never wanted.
+			if rollback && c.Bool("keep-going") {
+				return errors.New(`"--keep-going" cannot be used with "--rollback-on-failure"`)
+			}
+
+			return nil
+		},
+	}
+}
+
+// resolveInputPath sets path to the path of the profile if it is empty, and checks that the file
+// exists. Paths set by flags or env vars are validated as they are parsed.
+func resolveInputPath(ctx context.Context, path *string, cfg *GlobalFlags) error {
+	if *path != "" {
+		return nil
+	}
+
+	if cfg.profile.Path == "" {
+		return missingSettingError("path", "MINIFLUX_SYNC_PATH")
+	}
+
+	*path = cfg.profile.Path
+
+	return validateInputFile(ctx, *path, []string{".yaml", ".yml"})
+}
+
+// validateInputFile checks that the file at path exists, is not a directory, and has one of the
+// allowed extensions.
+func validateInputFile(ctx context.Context, path string, allowedExts []string) error {
+	if err := kitchensink.ValidateFileExtension(ctx, path, allowedExts); err != nil {
+		return errors.Wrap(err, "validating file extension")
+	}
+
+	info, err := os.Stat(path)
+	if os.IsNotExist(err) {
+		return errors.Wrap(err, "file does not exist")
+	}
+
+	if info.IsDir() {
+		return errors.New("file is a directory")
+	}
+
+	return nil
+}
@@ -0,0 +1,100 @@
+package config
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/urfave/cli/v2"
+)
+
+func TestRollbackOnFailureFlag(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		args []string
+		err  string
+	}{
+		"RollbackOnFailure": {
+			args: []string{"--rollback-on-failure"},
+		},
+		"KeepGoing": {
+			args: []string{"--keep-going"},
+		},
+		"Both": {
+			args: []string{"--keep-going", "--rollback-on-failure"},
+			err:  `"--keep-going" cannot be used with "--rollback-on-failure"`,
+		},
+		"BothDisabled": {
+			args: []string{"--keep-going", "--rollback-on-failure=false"},
+		},
+	}
+
+	for name, testCase := range tests {
+		tc := testCase
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			var keepGoing, rollbackOnFailure bool
+
+			app := &cli.App{
+				Flags: []cli.Flag{
+					keepGoingFlag(&keepGoing),
+					rollbackOnFailureFlag(&rollbackOnFailure),
+				},
+				Action: func(*cli.Context) error {
+					return nil
+				},
+			}
+
+			err := app.Run(append([]string{"miniflux-sync"}, tc.args...))
+			if tc.err != "" {
+				require.EqualError(t, err, tc.err)
+				return
+			}
+
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestSyncFlags_Journal(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		args      []string
+		journaled bool
+	}{
+		"Default": {
+			journaled: true,
+		},
+		"Disabled": {
+			args: []string{"--journal=false"},
+		},
+		"JournalFile": {
+			args:      []string{"--journal-file", "sync.journal"},
+			journaled: true,
+		},
+	}
+
+	for name, testCase := range tests {
+		tc := testCase
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			flags := &SyncFlags{}
+
+			app := &cli.App{
+				Flags: flags.Flags(context.Background()),
+				Action: func(*cli.Context) error {
+					return nil
+				},
+			}
+
+			require.NoError(t, app.Run(append([]string{"miniflux-sync"}, tc.args...)))
+			require.Equal(t, tc.journaled, flags.Journaled())
+		})
+	}
+}
@@ -0,0 +1,141 @@
+package config
+
+import (
+	"maps"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/urfave/cli/v2"
+)
+
+// transportFlags returns the flags for connecting to the Miniflux endpoint, such as TLS and proxy
+// settings.
+func (c *GlobalFlags) transportFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.StringFlag{
+			Name:        "ca-cert",
+			Usage:       "Path to a PEM bundle of CA certificates to trust, as well as the system ones.",
+			EnvVars:     []string{"MINIFLUX_SYNC_CA_CERT"},
+			Destination: &c.CACert,
+		},
+		&cli.StringFlag{
+			Name:        "client-cert",
+			Usage:       "Path to a PEM client certificate, for mutual TLS. Requires \"--client-key\".",
+			EnvVars:     []string{"MINIFLUX_SYNC_CLIENT_CERT"},
+			Destination: &c.ClientCert,
+		},
+		&cli.StringFlag{
+			Name:        "client-key",
+			Usage:       "Path to the PEM key of the client certificate. Requires \"--client-cert\".",
+			EnvVars:     []string{"MINIFLUX_SYNC_CLIENT_KEY"},
+			Destination: &c.ClientKey,
+		},
+		&cli.BoolFlag{
+			Name:        "insecure-skip-verify",
+			Usage:       "Do not verify the TLS certificate of the Miniflux endpoint. Not recommended.",
+			EnvVars:     []string{"MINIFLUX_SYNC_INSECURE_SKIP_VERIFY"},
+			Destination: &c.Insecure,
+			Value:       false,
+			Action: func(ctx *cli.Context, _ bool) error {
+				c.insecureSet = ctx.IsSet("insecure-skip-verify")
+				return nil
+			},
+		},
+		&cli.StringFlag{
+			Name:        "proxy",
+			Usage:       "URL of the HTTP proxy for Miniflux API requests. (default: from HTTPS_PROXY and HTTP_PROXY)",
+			EnvVars:     []string{"MINIFLUX_SYNC_PROXY"},
+			Destination: &c.Proxy,
+			Action: func(_ *cli.Context, s string) error {
+				return validateProxy(s)
+			},
+		},
+		&cli.StringSliceFlag{
+			Name:    "header",
+			Usage:   `Extra header for Miniflux API requests, as "Name: value", which can be repeated.`,
+			EnvVars: []string{"MINIFLUX_SYNC_HEADERS"},
+			Action: func(_ *cli.Context, headers []string) error {
+				parsed, err := parseHeaders(headers)
+				if err != nil {
+					return err
+				}
+
+				c.Headers = parsed
+
+				return nil
+			},
+		},
+	}
+}
+
+// resolveTransport fills the transport settings which were not set by flags or env vars from the
+// profile, and checks them. Headers are merged, with flags and env vars replacing headers of the
+// same name.
+func (c *GlobalFlags) resolveTransport() error {
+	if c.CACert == "" {
+		c.CACert = c.profile.CACert
+	}
+
+	if c.ClientCert == "" && c.ClientKey == "" {
+		c.ClientCert = c.profile.ClientCert
+		c.ClientKey = c.profile.ClientKey
+	}
+
+	if c.Proxy == "" {
+		c.Proxy = c.profile.Proxy
+	}
+
+	if !c.insecureSet {
+		c.Insecure = c.profile.Insecure
+	}
+
+	if len(c.profile.Headers) > 0 {
+		headers := map[string]string{}
+		for name, value := range c.profile.Headers {
+			headers[http.CanonicalHeaderKey(name)] = value
+		}
+
+		maps.Copy(headers, c.Headers)
+		c.Headers = headers
+	}
+
+	if (c.ClientCert == "") != (c.ClientKey == "") {
+		return errors.New(`"--client-cert" and "--client-key" must be used together`)
+	}
+
+	return validateProxy(c.Proxy)
+}
+
+// parseHeaders parses headers in the "Name: value" format.
+func parseHeaders(headers []string) (map[string]string, error) {
+	parsed := map[string]string{}
+
+	for _, header := range headers {
+		name, value, ok := strings.Cut(header, ":")
+		name = strings.TrimSpace(name)
+
+		if !ok || name == "" || strings.ContainsAny(name, " \t") {
+			return nil, errors.Errorf(`invalid header "%s", expected "Name: value"`, header)
+		}
+
+		parsed[http.CanonicalHeaderKey(name)] = strings.TrimSpace(value)
+	}
+
+	return parsed, nil
+}
+
+// validateProxy checks that proxy is empty, or an absolute URL.
+func validateProxy(proxy string) error {
+	if proxy == "" {
+		return nil
+	}
+
+	u, err := url.Parse(proxy)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return errors.Errorf(`invalid proxy "%s", expected a URL such as "http://proxy:3128"`, proxy)
+	}
+
+	return nil
+}
@@ -0,0 +1,171 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/urfave/cli/v2"
+)
+
+func TestParseHeaders(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		headers  []string
+		expected map[string]string
+		err      string
+	}{
+		"Valid": {
+			headers: []string{"cf-access-client-id: id", "X-Token:secret:with:colons"},
+			expected: map[string]string{
+				"Cf-Access-Client-Id": "id",
+				"X-Token":             "secret:with:colons",
+			},
+		},
+		"MissingColon": {
+			headers: []string{"X-Token secret"},
+			err:     `invalid header "X-Token secret", expected "Name: value"`,
+		},
+		"EmptyName": {
+			headers: []string{": value"},
+			err:     `invalid header ": value", expected "Name: value"`,
+		},
+	}
+
+	for name, testCase := range tests {
+		tc := testCase
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			headers, err := parseHeaders(tc.headers)
+			if tc.err != "" {
+				require.EqualError(t, err, tc.err)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, headers)
+		})
+	}
+}
+
+func TestResolveTransport(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		flags    GlobalFlags
+		expected GlobalFlags
+		err      string
+	}{
+		"FromProfile": {
+			flags: GlobalFlags{
+				profile: Profile{
+					CACert:     "/ca.pem",
+					ClientCert: "/client.crt",
+					ClientKey:  "/client.key",
+					Insecure:   true,
+					Proxy:      "http://proxy:3128",
+					Headers:    map[string]string{"x-team": "a"},
+				},
+			},
+			expected: GlobalFlags{
+				CACert:     "/ca.pem",
+				ClientCert: "/client.crt",
+				ClientKey:  "/client.key",
+				Insecure:   true,
+				Proxy:      "http://proxy:3128",
+				Headers:    map[string]string{"X-Team": "a"},
+			},
+		},
+		"FlagsOverrideProfile": {
+			flags: GlobalFlags{
+				CACert:  "/flag-ca.pem",
+				Proxy:   "http://flag-proxy:3128",
+				Headers: map[string]string{"X-Team": "b", "X-Other": "c"},
+				profile: Profile{
+					CACert:  "/ca.pem",
+					Proxy:   "http://proxy:3128",
+					Headers: map[string]string{"X-Team": "a", "X-Profile": "d"},
+				},
+			},
+			expected: GlobalFlags{
+				CACert:  "/flag-ca.pem",
+				Proxy:   "http://flag-proxy:3128",
+				Headers: map[string]string{"X-Team": "b", "X-Other": "c", "X-Profile": "d"},
+			},
+		},
+		"ClientCertWithoutKey": {
+			flags: GlobalFlags{ClientCert: "/client.crt"},
+			err:   `"--client-cert" and "--client-key" must be used together`,
+		},
+		"InvalidProxy": {
+			flags: GlobalFlags{profile: Profile{Proxy: "proxy:3128"}},
+			err:   `invalid proxy "proxy:3128", expected a URL such as "http://proxy:3128"`,
+		},
+	}
+
+	for name, testCase := range tests {
+		tc := testCase
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			err := tc.flags.resolveTransport()
+			if tc.err != "" {
+				require.EqualError(t, err, tc.err)
+				return
+			}
+
+			require.NoError(t, err)
+
+			tc.flags.profile = Profile{}
+			require.Equal(t, tc.expected, tc.flags)
+		})
+	}
+}
+
+func TestInsecureSkipVerifyFlag(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		args     []string
+		profile  bool
+		expected bool
+	}{
+		"FromProfile": {
+			profile:  true,
+			expected: true,
+		},
+		"FlagEnables": {
+			args:     []string{"--insecure-skip-verify"},
+			expected: true,
+		},
+		"FlagDisablesProfile": {
+			args:     []string{"--insecure-skip-verify=false"},
+			profile:  true,
+			expected: false,
+		},
+	}
+
+	for name, testCase := range tests {
+		tc := testCase
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			cfg := New("test")
+
+			app := &cli.App{
+				Flags: cfg.Flags(),
+				Action: func(*cli.Context) error {
+					cfg.profile = Profile{Insecure: tc.profile}
+					return cfg.resolveTransport()
+				},
+			}
+
+			require.NoError(t, app.Run(append([]string{"miniflux-sync"}, tc.args...)))
+			require.Equal(t, tc.expected, cfg.Insecure)
+		})
+	}
+}
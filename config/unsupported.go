@@ -0,0 +1,46 @@
+package config
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/urfave/cli/v2"
+)
+
+const (
+	// UnsupportedOptionsWarn ignores feed options which the Miniflux server does not support, with a
+	// warning.
+	UnsupportedOptionsWarn = "warn"
+
+	// UnsupportedOptionsFail fails when the data file uses feed options which the Miniflux server
+	// does not support.
+	UnsupportedOptionsFail = "fail"
+)
+
+// unsupportedOptionsModes is the list of supported values for the unsupported options flag.
+var unsupportedOptionsModes = []string{UnsupportedOptionsWarn, UnsupportedOptionsFail}
+
+// unsupportedOptionsFlag returns the flag used to select what happens when the data file uses feed
+// options which the Miniflux server is too old to support. The options can only be checked once
+// connected, as the check needs the server version, so there is no offline check.
+func unsupportedOptionsFlag(destination *string) *cli.StringFlag {
+	return &cli.StringFlag{
+		Name: "unsupported-options",
+		Usage: fmt.Sprintf(
+			"What to do with feed options the Miniflux version does not support, checked once connected: %s.",
+			strings.Join(unsupportedOptionsModes, ", "),
+		),
+		EnvVars:     []string{"MINIFLUX_SYNC_UNSUPPORTED_OPTIONS"},
+		Destination: destination,
+		Value:       UnsupportedOptionsWarn,
+		Action: func(_ *cli.Context, s string) error {
+			if !slices.Contains(unsupportedOptionsModes, s) {
+				return errors.Errorf(`unsupported value for "--unsupported-options": "%s"`, s)
+			}
+
+			return nil
+		},
+	}
+}
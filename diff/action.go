@@ -1,5 +1,7 @@
 package diff
 
+import "encoding/json"
+
 // ActionType represents the type of action to be performed.
 type ActionType string
 
@@ -21,9 +23,50 @@ const (
 )
 
 // Action represents an action to be performed to sync the local and remote state.
+// FeedOptions holds the desired options, and RemoteFeedOptions the current remote options of the
+// feed, which are only set for UpdateFeed actions. DependsOn lists the IDs of the actions which
+// must be completed before this one.
 type Action struct {
-	Type          ActionType
-	CategoryTitle string
-	FeedURL       string
-	FeedOptions   FeedOptions
+	Type              ActionType  `json:"type"`
+	CategoryTitle     string      `json:"category_title"`
+	FeedURL           string      `json:"feed_url,omitempty"`
+	FeedOptions       FeedOptions `json:"feed_options"`
+	RemoteFeedOptions FeedOptions `json:"remote_feed_options"`
+	DependsOn         []string    `json:"depends_on,omitempty"`
+}
+
+// OptionChanges returns the feed options changed by the action. New feeds are compared against
+// unset options.
+func (a Action) OptionChanges() []OptionChange {
+	switch a.Type {
+	case CreateFeed, UpdateFeed:
+		return a.FeedOptions.Changes(a.RemoteFeedOptions)
+	default:
+		return []OptionChange{}
+	}
+}
+
+// MarshalJSON encodes the action with the secret remote feed options masked, so the current
+// credentials of a feed are never written to plan files or journals. A secret which matches the
+// desired value is kept, as it is already in FeedOptions, so OptionChanges reports the same
+// options after a round trip.
+func (a Action) MarshalJSON() ([]byte, error) {
+	type plainAction Action
+
+	masked := plainAction(a)
+	remote := &masked.RemoteFeedOptions
+	remote.Password = maskSecret(remote.Password, a.FeedOptions.Password)
+	remote.Cookie = maskSecret(remote.Cookie, a.FeedOptions.Cookie)
+
+	return json.Marshal(masked) //nolint:wrapcheck
+}
+
+// maskSecret returns the masked value of a secret option, unless it is unset or matches desired.
+func maskSecret(value *string, desired *string) *string {
+	if value == nil || stringPtrEqual(value, desired) {
+		return value
+	}
+
+	masked := maskedValue
+	return &masked
 }
@@ -1,6 +1,8 @@
 package diff
 
-// ActionSorter sorts actions by type and then by relevant fields within each type.
+// ActionSorter sorts actions by type and then by relevant fields within each type. It does not
+// account for dependencies between actions, so is used by TopologicalSort to order actions which
+// are ready at the same time.
 type ActionSorter []Action
 
 // Len implements the sort.Interface.
@@ -13,16 +15,18 @@ func (a ActionSorter) Swap(i int, j int) {
 	a[i], a[j] = a[j], a[i]
 }
 
+// actionTypeOrder defines the order of action types.
+var actionTypeOrder = map[ActionType]int{ //nolint:gochecknoglobals
+	DeleteFeed:     0,
+	DeleteCategory: 1,
+	CreateCategory: 2,
+	CreateFeed:     3,
+	UpdateFeed:     4,
+}
+
 // Less implements the sort.Interface.
 func (a ActionSorter) Less(i int, j int) bool { //nolint:varnamelen
-	// Define the order of action types.
-	order := map[ActionType]int{
-		DeleteFeed:     0,
-		DeleteCategory: 1,
-		CreateCategory: 2,
-		CreateFeed:     3,
-		UpdateFeed:     4,
-	}
+	order := actionTypeOrder
 
 	// First, sort by action type.
 	if order[a[i].Type] != order[a[j].Type] {
@@ -0,0 +1,56 @@
+package diff_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/revett/miniflux-sync/diff"
+	"github.com/stretchr/testify/require"
+)
+
+func TestActionMarshalJSON(t *testing.T) {
+	t.Parallel()
+
+	oldPassword := "old-password"
+	newPassword := "new-password"
+	cookie := "session=abc"
+	article := "article"
+	content := "div.content"
+
+	action := diff.Action{
+		Type:          diff.UpdateFeed,
+		CategoryTitle: "Tech",
+		FeedURL:       "https://tech.com/feed",
+		FeedOptions: diff.FeedOptions{
+			Password:     &newPassword,
+			Cookie:       &cookie,
+			ScraperRules: &content,
+		},
+		RemoteFeedOptions: diff.FeedOptions{
+			Password:     &oldPassword,
+			Cookie:       &cookie,
+			ScraperRules: &article,
+		},
+	}
+
+	data, err := json.Marshal(action)
+	require.NoError(t, err)
+	require.NotContains(t, string(data), oldPassword)
+
+	var decoded diff.Action
+	require.NoError(t, json.Unmarshal(data, &decoded))
+
+	require.Equal(t, "********", *decoded.RemoteFeedOptions.Password)
+	require.Equal(t, action.FeedOptions, decoded.FeedOptions)
+
+	// The same options are changed, and they are displayed the same once masked.
+	expected := action.OptionChanges()
+	actual := decoded.OptionChanges()
+	require.Len(t, actual, len(expected))
+	for i := range expected {
+		require.Equal(t, expected[i].Masked(), actual[i].Masked())
+	}
+
+	// The action itself is not modified.
+	require.Equal(t, oldPassword, *action.RemoteFeedOptions.Password)
+}
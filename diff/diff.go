@@ -1,9 +1,9 @@
 package diff
 
-import "sort"
+import "github.com/pkg/errors"
 
 // CalculateDiff calculates the differences between the local and remote state and returns the
-// actions to be performed.
+// actions to be performed, with their dependencies, in a valid order.
 func CalculateDiff(local *State, remote *State) ([]Action, error) { //nolint:cyclop
 	actions := []Action{}
 
@@ -61,19 +61,25 @@ func CalculateDiff(local *State, remote *State) ([]Action, error) { //nolint:cyc
 				remoteOptions := remote.GetFeedOptions(localFeed.URL)
 				if needsUpdate(localFeed.Options, remoteOptions) {
 					actions = append(actions, Action{
-						Type:          UpdateFeed,
-						CategoryTitle: categoryTitle,
-						FeedURL:       localFeed.URL,
-						FeedOptions:   localFeed.Options,
+						Type:              UpdateFeed,
+						CategoryTitle:     categoryTitle,
+						FeedURL:           localFeed.URL,
+						FeedOptions:       localFeed.Options,
+						RemoteFeedOptions: remoteOptions,
 					})
 				}
 			}
 		}
 	}
 
-	sort.Sort(ActionSorter(actions))
+	addDependencies(actions)
 
-	return actions, nil
+	sorted, err := TopologicalSort(actions)
+	if err != nil {
+		return nil, errors.Wrap(err, "ordering actions")
+	}
+
+	return sorted, nil
 }
 
 // needsUpdate checks if local options differ from remote and require an update.
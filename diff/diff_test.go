@@ -1,6 +1,9 @@
 package diff_test
 
 import (
+	"fmt"
+	"slices"
+	"strings"
 	"testing"
 
 	"github.com/revett/miniflux-sync/diff"
@@ -70,6 +73,9 @@ func TestCalculateDiff(t *testing.T) { //nolint:funlen
 				{
 					Type:          diff.DeleteCategory,
 					CategoryTitle: "General",
+					DependsOn: []string{
+						`["DeleteFeed","General","https://tech.com/feed"]`,
+					},
 				},
 				{
 					Type:          diff.CreateCategory,
@@ -79,6 +85,10 @@ func TestCalculateDiff(t *testing.T) { //nolint:funlen
 					Type:          diff.CreateFeed,
 					FeedURL:       "https://tech.com/feed",
 					CategoryTitle: "Tech",
+					DependsOn: []string{
+						`["CreateCategory","Tech",""]`,
+						`["DeleteFeed","General","https://tech.com/feed"]`,
+					},
 				},
 			},
 		},
@@ -99,6 +109,9 @@ func TestCalculateDiff(t *testing.T) { //nolint:funlen
 				{
 					Type:          diff.DeleteCategory,
 					CategoryTitle: "Tech",
+					DependsOn: []string{
+						`["DeleteFeed","Tech","https://tech.com/feed"]`,
+					},
 				},
 			},
 		},
@@ -126,6 +139,9 @@ func TestCalculateDiff(t *testing.T) { //nolint:funlen
 					Type:          diff.CreateFeed,
 					FeedURL:       "https://music.com/feed",
 					CategoryTitle: "Music",
+					DependsOn: []string{
+						`["CreateCategory","Music",""]`,
+					},
 				},
 			},
 		},
@@ -153,6 +169,9 @@ func TestCalculateDiff(t *testing.T) { //nolint:funlen
 				{
 					Type:          diff.DeleteCategory,
 					CategoryTitle: "Tech",
+					DependsOn: []string{
+						`["DeleteFeed","Tech","https://tech.com/feed"]`,
+					},
 				},
 			},
 		},
@@ -188,21 +207,33 @@ func TestCalculateDiff(t *testing.T) { //nolint:funlen
 					Type:          diff.CreateFeed,
 					FeedURL:       "https://music.com/feed",
 					CategoryTitle: "Music",
+					DependsOn: []string{
+						`["CreateCategory","Music",""]`,
+					},
 				},
 				{
 					Type:          diff.CreateFeed,
 					FeedURL:       "https://news.com/feed",
 					CategoryTitle: "News",
+					DependsOn: []string{
+						`["CreateCategory","News",""]`,
+					},
 				},
 				{
 					Type:          diff.CreateFeed,
 					FeedURL:       "https://newtech.com/feed",
 					CategoryTitle: "Tech",
+					DependsOn: []string{
+						`["CreateCategory","Tech",""]`,
+					},
 				},
 				{
 					Type:          diff.CreateFeed,
 					FeedURL:       "https://tech.com/feed",
 					CategoryTitle: "Tech",
+					DependsOn: []string{
+						`["CreateCategory","Tech",""]`,
+					},
 				},
 			},
 		},
@@ -236,6 +267,9 @@ func TestCalculateDiff(t *testing.T) { //nolint:funlen
 				{
 					Type:          diff.DeleteCategory,
 					CategoryTitle: "Music",
+					DependsOn: []string{
+						`["DeleteFeed","Music","https://music.com/feed"]`,
+					},
 				},
 			},
 		},
@@ -274,6 +308,11 @@ func TestCalculateDiff(t *testing.T) { //nolint:funlen
 				{
 					Type:          diff.DeleteCategory,
 					CategoryTitle: "Tech",
+					DependsOn: []string{
+						`["DeleteFeed","Tech","https://newtech.com/feed"]`,
+						`["DeleteFeed","Tech","https://oldtech.com/feed"]`,
+						`["DeleteFeed","Tech","https://tech.com/feed"]`,
+					},
 				},
 				{
 					Type:          diff.CreateCategory,
@@ -283,16 +322,28 @@ func TestCalculateDiff(t *testing.T) { //nolint:funlen
 					Type:          diff.CreateFeed,
 					FeedURL:       "https://newtech.com/feed",
 					CategoryTitle: "General",
+					DependsOn: []string{
+						`["CreateCategory","General",""]`,
+						`["DeleteFeed","Tech","https://newtech.com/feed"]`,
+					},
 				},
 				{
 					Type:          diff.CreateFeed,
 					FeedURL:       "https://oldtech.com/feed",
 					CategoryTitle: "General",
+					DependsOn: []string{
+						`["CreateCategory","General",""]`,
+						`["DeleteFeed","Tech","https://oldtech.com/feed"]`,
+					},
 				},
 				{
 					Type:          diff.CreateFeed,
 					FeedURL:       "https://tech.com/feed",
 					CategoryTitle: "General",
+					DependsOn: []string{
+						`["CreateCategory","General",""]`,
+						`["DeleteFeed","Tech","https://tech.com/feed"]`,
+					},
 				},
 			},
 		},
@@ -317,3 +368,112 @@ func TestCalculateDiff(t *testing.T) { //nolint:funlen
 		})
 	}
 }
+
+func TestCalculateDiff_UpdateFeed(t *testing.T) {
+	t.Parallel()
+
+	article := "article"
+	content := "div.content"
+
+	local := &diff.State{
+		FeedURLsByCategoryTitle: map[string][]string{
+			"Tech": {"https://tech.com/feed"},
+		},
+		FeedsByCategoryTitle: map[string][]diff.Feed{
+			"Tech": {
+				{URL: "https://tech.com/feed", Options: diff.FeedOptions{ScraperRules: &content}},
+			},
+		},
+	}
+	remote := &diff.State{
+		FeedURLsByCategoryTitle: map[string][]string{
+			"Tech": {"https://tech.com/feed"},
+		},
+		FeedsByCategoryTitle: map[string][]diff.Feed{
+			"Tech": {
+				{URL: "https://tech.com/feed", Options: diff.FeedOptions{ScraperRules: &article}},
+			},
+		},
+	}
+
+	actions, err := diff.CalculateDiff(local, remote)
+	require.NoError(t, err)
+	require.Equal(t, []diff.Action{
+		{
+			Type:              diff.UpdateFeed,
+			CategoryTitle:     "Tech",
+			FeedURL:           "https://tech.com/feed",
+			FeedOptions:       diff.FeedOptions{ScraperRules: &content},
+			RemoteFeedOptions: diff.FeedOptions{ScraperRules: &article},
+		},
+	}, actions)
+	require.Equal(t, []diff.OptionChange{
+		{Field: "scraper_rules", Before: "article", After: "div.content"},
+	}, actions[0].OptionChanges())
+}
+
+// movedCategoriesStates returns states in which every one of n remote categories is replaced by a
+// local category holding the same two feeds and a new one, so the diff deletes and creates every
+// category, and moves every feed.
+func movedCategoriesStates(n int) (*diff.State, *diff.State) {
+	local := &diff.State{FeedURLsByCategoryTitle: map[string][]string{}}
+	remote := &diff.State{FeedURLsByCategoryTitle: map[string][]string{}}
+
+	for i := range n {
+		feedURLs := []string{
+			fmt.Sprintf("https://example.com/%d/a.xml", i),
+			fmt.Sprintf("https://example.com/%d/b.xml", i),
+		}
+
+		remote.FeedURLsByCategoryTitle[fmt.Sprintf("old-%d", i)] = feedURLs
+		local.FeedURLsByCategoryTitle[fmt.Sprintf("new-%d", i)] = append(
+			slices.Clone(feedURLs), fmt.Sprintf("https://example.com/%d/c.xml", i),
+		)
+	}
+
+	return local, remote
+}
+
+func TestCalculateDiff_ManyActions(t *testing.T) {
+	t.Parallel()
+
+	local, remote := movedCategoriesStates(1000)
+
+	actions, err := diff.CalculateDiff(local, remote)
+	require.NoError(t, err)
+	require.Len(t, actions, 7000)
+
+	for _, action := range actions {
+		switch action.Type {
+		case diff.CreateFeed:
+			expected := []string{
+				diff.Action{Type: diff.CreateCategory, CategoryTitle: action.CategoryTitle}.ID(),
+			}
+
+			if !strings.HasSuffix(action.FeedURL, "/c.xml") {
+				oldCategory := "old-" + strings.TrimPrefix(action.CategoryTitle, "new-")
+				expected = append(expected, diff.Action{
+					Type: diff.DeleteFeed, CategoryTitle: oldCategory, FeedURL: action.FeedURL,
+				}.ID())
+			}
+
+			require.ElementsMatch(t, expected, action.DependsOn, action.ID())
+
+		case diff.DeleteCategory:
+			require.Len(t, action.DependsOn, 2, action.ID())
+
+		default:
+			require.Empty(t, action.DependsOn, action.ID())
+		}
+	}
+}
+
+func BenchmarkCalculateDiff(b *testing.B) {
+	local, remote := movedCategoriesStates(1000)
+
+	for range b.N {
+		if _, err := diff.CalculateDiff(local, remote); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package diff
+
+// Drift describes how the remote state differs from the local state, built from the actions
+// required to sync them.
+type Drift struct {
+	// LocalOnlyCategories are categories in the local state which do not exist remotely.
+	LocalOnlyCategories []string
+	// LocalOnlyFeeds are feeds in the local state which do not exist remotely, in that category.
+	LocalOnlyFeeds []Action
+	// RemoteOnlyCategories are remote categories which are not in the local state.
+	RemoteOnlyCategories []string
+	// RemoteOnlyFeeds are remote feeds which are not in the local state, in that category.
+	RemoteOnlyFeeds []Action
+	// OptionMismatches are feeds whose remote options differ from the local options.
+	OptionMismatches []Action
+}
+
+// NewDrift builds a Drift from the actions returned by CalculateDiff.
+func NewDrift(actions []Action) Drift {
+	drift := Drift{
+		LocalOnlyCategories:  []string{},
+		LocalOnlyFeeds:       []Action{},
+		RemoteOnlyCategories: []string{},
+		RemoteOnlyFeeds:      []Action{},
+		OptionMismatches:     []Action{},
+	}
+
+	for _, action := range actions {
+		switch action.Type {
+		case CreateCategory:
+			drift.LocalOnlyCategories = append(drift.LocalOnlyCategories, action.CategoryTitle)
+		case CreateFeed:
+			drift.LocalOnlyFeeds = append(drift.LocalOnlyFeeds, action)
+		case DeleteCategory:
+			drift.RemoteOnlyCategories = append(drift.RemoteOnlyCategories, action.CategoryTitle)
+		case DeleteFeed:
+			drift.RemoteOnlyFeeds = append(drift.RemoteOnlyFeeds, action)
+		case UpdateFeed:
+			drift.OptionMismatches = append(drift.OptionMismatches, action)
+		}
+	}
+
+	return drift
+}
+
+// Detected returns true if the remote state differs from the local state in any way.
+func (d Drift) Detected() bool {
+	return len(d.LocalOnlyCategories) > 0 ||
+		len(d.LocalOnlyFeeds) > 0 ||
+		len(d.RemoteOnlyCategories) > 0 ||
+		len(d.RemoteOnlyFeeds) > 0 ||
+		len(d.OptionMismatches) > 0
+}
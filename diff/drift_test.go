@@ -0,0 +1,37 @@
+package diff_test
+
+import (
+	"testing"
+
+	"github.com/revett/miniflux-sync/diff"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewDrift(t *testing.T) {
+	t.Parallel()
+
+	actions := []diff.Action{
+		{Type: diff.DeleteFeed, CategoryTitle: "Old", FeedURL: "https://old.com/feed"},
+		{Type: diff.DeleteCategory, CategoryTitle: "Old"},
+		{Type: diff.CreateCategory, CategoryTitle: "Music"},
+		{Type: diff.CreateFeed, CategoryTitle: "Music", FeedURL: "https://music.com/feed"},
+		{Type: diff.UpdateFeed, CategoryTitle: "Tech", FeedURL: "https://tech.com/feed"},
+	}
+
+	drift := diff.NewDrift(actions)
+
+	require.True(t, drift.Detected())
+	require.Equal(t, diff.Drift{
+		LocalOnlyCategories:  []string{"Music"},
+		LocalOnlyFeeds:       []diff.Action{actions[3]},
+		RemoteOnlyCategories: []string{"Old"},
+		RemoteOnlyFeeds:      []diff.Action{actions[0]},
+		OptionMismatches:     []diff.Action{actions[4]},
+	}, drift)
+}
+
+func TestNewDrift_NoActions(t *testing.T) {
+	t.Parallel()
+
+	require.False(t, diff.NewDrift([]diff.Action{}).Detected())
+}
@@ -3,21 +3,21 @@ package diff
 // FeedOptions represents the configurable options for a Miniflux feed.
 // All fields are pointers to distinguish between "not set" and "set to zero value".
 type FeedOptions struct {
-	Crawler                     *bool   `yaml:"crawler,omitempty"`
-	Username                    *string `yaml:"username,omitempty"`
-	Password                    *string `yaml:"password,omitempty"`
-	UserAgent                   *string `yaml:"user_agent,omitempty"`
-	Cookie                      *string `yaml:"cookie,omitempty"`
-	Disabled                    *bool   `yaml:"disabled,omitempty"`
-	IgnoreHTTPCache             *bool   `yaml:"ignore_http_cache,omitempty"`
-	FetchViaProxy               *bool   `yaml:"fetch_via_proxy,omitempty"`
-	AllowSelfSignedCertificates *bool   `yaml:"allow_self_signed_certificates,omitempty"`
-	DisableHTTP2                *bool   `yaml:"disable_http2,omitempty"`
-	ScraperRules                *string `yaml:"scraper_rules,omitempty"`
-	RewriteRules                *string `yaml:"rewrite_rules,omitempty"`
-	BlocklistRules              *string `yaml:"blocklist_rules,omitempty"`
-	KeeplistRules               *string `yaml:"keeplist_rules,omitempty"`
-	HideGlobally                *bool   `yaml:"hide_globally,omitempty"`
+	Crawler                     *bool   `yaml:"crawler,omitempty" json:"crawler,omitempty"`
+	Username                    *string `yaml:"username,omitempty" json:"username,omitempty"`
+	Password                    *string `yaml:"password,omitempty" json:"password,omitempty"`
+	UserAgent                   *string `yaml:"user_agent,omitempty" json:"user_agent,omitempty"`
+	Cookie                      *string `yaml:"cookie,omitempty" json:"cookie,omitempty"`
+	Disabled                    *bool   `yaml:"disabled,omitempty" json:"disabled,omitempty"`
+	IgnoreHTTPCache             *bool   `yaml:"ignore_http_cache,omitempty" json:"ignore_http_cache,omitempty"`
+	FetchViaProxy               *bool   `yaml:"fetch_via_proxy,omitempty" json:"fetch_via_proxy,omitempty"`
+	AllowSelfSignedCertificates *bool   `yaml:"allow_self_signed_certificates,omitempty" json:"allow_self_signed_certificates,omitempty"`
+	DisableHTTP2                *bool   `yaml:"disable_http2,omitempty" json:"disable_http2,omitempty"`
+	ScraperRules                *string `yaml:"scraper_rules,omitempty" json:"scraper_rules,omitempty"`
+	RewriteRules                *string `yaml:"rewrite_rules,omitempty" json:"rewrite_rules,omitempty"`
+	BlocklistRules              *string `yaml:"blocklist_rules,omitempty" json:"blocklist_rules,omitempty"`
+	KeeplistRules               *string `yaml:"keeplist_rules,omitempty" json:"keeplist_rules,omitempty"`
+	HideGlobally                *bool   `yaml:"hide_globally,omitempty" json:"hide_globally,omitempty"`
 }
 
 // Feed represents a feed with its URL and optional configuration.
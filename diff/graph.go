@@ -0,0 +1,215 @@
+package diff
+
+import (
+	"container/heap"
+	"encoding/json"
+	"slices"
+	"sort"
+
+	"github.com/pkg/errors"
+)
+
+// ErrDependencyCycle is returned when actions depend on each other, so cannot be ordered.
+var ErrDependencyCycle = errors.New("actions contain a dependency cycle")
+
+// actionKey finds actions of a type by their category title or feed URL.
+type actionKey struct {
+	Type  ActionType
+	Value string
+}
+
+// actionIndex holds the IDs of a set of actions, by category title and by feed URL, so that the
+// actions an action depends on can be found without comparing it to every other action.
+type actionIndex struct {
+	byCategory map[actionKey][]string
+	byFeedURL  map[actionKey][]string
+}
+
+// newActionIndex indexes the IDs of actions, which are computed once for each action.
+func newActionIndex(actions []Action) actionIndex {
+	index := actionIndex{
+		byCategory: map[actionKey][]string{},
+		byFeedURL:  map[actionKey][]string{},
+	}
+
+	for _, action := range actions {
+		id := action.ID()
+
+		categoryKey := actionKey{Type: action.Type, Value: action.CategoryTitle}
+		index.byCategory[categoryKey] = append(index.byCategory[categoryKey], id)
+
+		if action.FeedURL != "" {
+			feedURLKey := actionKey{Type: action.Type, Value: action.FeedURL}
+			index.byFeedURL[feedURLKey] = append(index.byFeedURL[feedURLKey], id)
+		}
+	}
+
+	return index
+}
+
+// dependencyRule returns the IDs of the actions which action depends on. Each action type has a
+// rule describing which other actions must be completed before it can be performed.
+type dependencyRule func(action Action, index actionIndex) []string
+
+// dependencyRules holds the dependency rule for each action type. Action types without a rule
+// have no dependencies. A rule only matches actions of other types, so an action never depends on
+// itself.
+var dependencyRules = map[ActionType]dependencyRule{
+	// A feed can only be created once its category exists, and once any feed with the same URL
+	// has been deleted from another category, as Miniflux does not allow duplicate feed URLs.
+	CreateFeed: func(action Action, index actionIndex) []string {
+		return slices.Concat(
+			index.byCategory[actionKey{Type: CreateCategory, Value: action.CategoryTitle}],
+			index.byFeedURL[actionKey{Type: DeleteFeed, Value: action.FeedURL}],
+		)
+	},
+
+	// A category can only be deleted once the feeds within it have been deleted, as Miniflux
+	// deletes a category's feeds along with it.
+	DeleteCategory: func(action Action, index actionIndex) []string {
+		return slices.Clone(index.byCategory[actionKey{Type: DeleteFeed, Value: action.CategoryTitle}])
+	},
+}
+
+// ID returns an identifier for the action, unique within a set of actions from CalculateDiff. The
+// fields are encoded as a JSON array, so titles and URLs containing any character cannot collide.
+func (a Action) ID() string {
+	id, _ := json.Marshal([]string{string(a.Type), a.CategoryTitle, a.FeedURL}) //nolint:errchkjson
+	return string(id)
+}
+
+// addDependencies sets DependsOn for each action, using the dependency rule for its type.
+func addDependencies(actions []Action) {
+	index := newActionIndex(actions)
+
+	for i, action := range actions {
+		rule, ok := dependencyRules[action.Type]
+		if !ok {
+			continue
+		}
+
+		actions[i].DependsOn = append(actions[i].DependsOn, rule(action, index)...)
+		sort.Strings(actions[i].DependsOn)
+	}
+}
+
+// Graph holds the dependencies between a set of actions, as indexes into that set.
+type Graph struct {
+	// Dependencies holds the indexes of the actions that each action depends on.
+	Dependencies [][]int
+
+	// Dependents holds the indexes of the actions that depend on each action.
+	Dependents [][]int
+}
+
+// NewGraph builds the dependency graph of actions. An error is returned if two actions have the
+// same ID, or if an action has an unknown dependency.
+func NewGraph(actions []Action) (*Graph, error) {
+	indexByID := make(map[string]int, len(actions))
+	for i, action := range actions {
+		if _, exists := indexByID[action.ID()]; exists {
+			return nil, errors.Errorf(`duplicate action: %s`, action.ID())
+		}
+
+		indexByID[action.ID()] = i
+	}
+
+	graph := &Graph{
+		Dependencies: make([][]int, len(actions)),
+		Dependents:   make([][]int, len(actions)),
+	}
+
+	for i, action := range actions {
+		for _, dependencyID := range action.DependsOn {
+			dependencyIndex, ok := indexByID[dependencyID]
+			if !ok {
+				return nil, errors.Errorf(
+					`action %s depends on unknown action: %s`, action.ID(), dependencyID,
+				)
+			}
+
+			graph.Dependencies[i] = append(graph.Dependencies[i], dependencyIndex)
+			graph.Dependents[dependencyIndex] = append(graph.Dependents[dependencyIndex], i)
+		}
+	}
+
+	return graph, nil
+}
+
+// TopologicalSort orders actions so that every action comes after the actions it depends on.
+// Actions which are ready at the same time are ordered using ActionSorter, so the result is
+// deterministic. An error is returned if an action has an unknown dependency, or if the
+// dependencies contain a cycle.
+func TopologicalSort(actions []Action) ([]Action, error) {
+	graph, err := NewGraph(actions)
+	if err != nil {
+		return nil, err
+	}
+
+	unmetDependencies := make([]int, len(actions))
+	ready := &readyQueue{actions: actions}
+
+	for i := range actions {
+		unmetDependencies[i] = len(graph.Dependencies[i])
+		if unmetDependencies[i] == 0 {
+			ready.indexes = append(ready.indexes, i)
+		}
+	}
+
+	heap.Init(ready)
+
+	sorted := make([]Action, 0, len(actions))
+
+	for ready.Len() > 0 {
+		next := heap.Pop(ready).(int) //nolint:forcetypeassert
+		sorted = append(sorted, actions[next])
+
+		for _, dependentIndex := range graph.Dependents[next] {
+			unmetDependencies[dependentIndex]--
+			if unmetDependencies[dependentIndex] == 0 {
+				heap.Push(ready, dependentIndex)
+			}
+		}
+	}
+
+	if len(sorted) != len(actions) {
+		return nil, ErrDependencyCycle
+	}
+
+	return sorted, nil
+}
+
+// readyQueue is a heap of the indexes of actions which are ready to be performed, ordered using
+// ActionSorter.
+type readyQueue struct {
+	actions ActionSorter
+	indexes []int
+}
+
+// Len implements heap.Interface.
+func (q *readyQueue) Len() int {
+	return len(q.indexes)
+}
+
+// Less implements heap.Interface.
+func (q *readyQueue) Less(i int, j int) bool {
+	return q.actions.Less(q.indexes[i], q.indexes[j])
+}
+
+// Swap implements heap.Interface.
+func (q *readyQueue) Swap(i int, j int) {
+	q.indexes[i], q.indexes[j] = q.indexes[j], q.indexes[i]
+}
+
+// Push implements heap.Interface.
+func (q *readyQueue) Push(x any) {
+	q.indexes = append(q.indexes, x.(int)) //nolint:forcetypeassert
+}
+
+// Pop implements heap.Interface.
+func (q *readyQueue) Pop() any {
+	last := q.indexes[len(q.indexes)-1]
+	q.indexes = q.indexes[:len(q.indexes)-1]
+
+	return last
+}
@@ -0,0 +1,128 @@
+package diff_test
+
+import (
+	"testing"
+
+	"github.com/revett/miniflux-sync/diff"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTopologicalSort(t *testing.T) { //nolint:funlen
+	t.Parallel()
+
+	tests := map[string]struct {
+		input    []diff.Action
+		expected []diff.Action
+		err      string
+	}{
+		"NoDependencies": {
+			input: []diff.Action{
+				{Type: diff.CreateCategory, CategoryTitle: "B"},
+				{Type: diff.CreateCategory, CategoryTitle: "A"},
+			},
+			expected: []diff.Action{
+				{Type: diff.CreateCategory, CategoryTitle: "A"},
+				{Type: diff.CreateCategory, CategoryTitle: "B"},
+			},
+		},
+
+		"DependencyBeforeDependent": {
+			input: []diff.Action{
+				{
+					Type:          diff.CreateFeed,
+					CategoryTitle: "Music",
+					FeedURL:       "https://music.com/feed",
+					DependsOn:     []string{`["CreateCategory","Music",""]`},
+				},
+				{Type: diff.CreateCategory, CategoryTitle: "Music"},
+			},
+			expected: []diff.Action{
+				{Type: diff.CreateCategory, CategoryTitle: "Music"},
+				{
+					Type:          diff.CreateFeed,
+					CategoryTitle: "Music",
+					FeedURL:       "https://music.com/feed",
+					DependsOn:     []string{`["CreateCategory","Music",""]`},
+				},
+			},
+		},
+
+		"DependencyOverridesTypeOrder": {
+			input: []diff.Action{
+				{
+					Type:          diff.DeleteFeed,
+					CategoryTitle: "Tech",
+					FeedURL:       "https://tech.com/feed",
+					DependsOn:     []string{`["UpdateFeed","Tech","https://other.com/feed"]`},
+				},
+				{Type: diff.UpdateFeed, CategoryTitle: "Tech", FeedURL: "https://other.com/feed"},
+			},
+			expected: []diff.Action{
+				{Type: diff.UpdateFeed, CategoryTitle: "Tech", FeedURL: "https://other.com/feed"},
+				{
+					Type:          diff.DeleteFeed,
+					CategoryTitle: "Tech",
+					FeedURL:       "https://tech.com/feed",
+					DependsOn:     []string{`["UpdateFeed","Tech","https://other.com/feed"]`},
+				},
+			},
+		},
+
+		"Cycle": {
+			input: []diff.Action{
+				{Type: diff.CreateCategory, CategoryTitle: "A", DependsOn: []string{`["CreateCategory","B",""]`}},
+				{Type: diff.CreateCategory, CategoryTitle: "B", DependsOn: []string{`["CreateCategory","A",""]`}},
+			},
+			err: "cycle",
+		},
+
+		"UnknownDependency": {
+			input: []diff.Action{
+				{Type: diff.CreateCategory, CategoryTitle: "A", DependsOn: []string{`["CreateCategory","B",""]`}},
+			},
+			err: "unknown action",
+		},
+
+		"DuplicateAction": {
+			input: []diff.Action{
+				{Type: diff.CreateCategory, CategoryTitle: "A"},
+				{Type: diff.CreateCategory, CategoryTitle: "A"},
+			},
+			err: "duplicate action",
+		},
+	}
+
+	for name, testCase := range tests {
+		tc := testCase
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			sorted, err := diff.TopologicalSort(tc.input)
+			if tc.err != "" {
+				require.ErrorContains(t, err, tc.err)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, sorted)
+		})
+	}
+}
+
+func TestActionID(t *testing.T) {
+	t.Parallel()
+
+	require.Equal(
+		t,
+		`["CreateFeed","Tech","https://tech.com/feed"]`,
+		diff.Action{Type: diff.CreateFeed, CategoryTitle: "Tech", FeedURL: "https://tech.com/feed"}.ID(),
+	)
+
+	// Separators within titles and URLs cannot make the IDs of different actions collide.
+	require.NotEqual(
+		t,
+		diff.Action{Type: diff.CreateFeed, CategoryTitle: "a:b", FeedURL: "c"}.ID(),
+		diff.Action{Type: diff.CreateFeed, CategoryTitle: "a", FeedURL: "b:c"}.ID(),
+	)
+}
@@ -0,0 +1,8 @@
+package diff
+
+// Identity is the Miniflux account which a data file is meant to be synced to. Either field can be
+// empty, in which case it is not checked.
+type Identity struct {
+	Username string `yaml:"username" json:"username,omitempty"`
+	Endpoint string `yaml:"endpoint" json:"endpoint,omitempty"`
+}
@@ -0,0 +1,130 @@
+package diff
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// maskedValue replaces the values of secret feed options when they are displayed.
+const maskedValue = "********"
+
+// OptionChange represents a single feed option that differs between the desired and current
+// options of a feed. Before and After are nil when the option is not set.
+type OptionChange struct {
+	Field  string `json:"field"`
+	Before any    `json:"before"`
+	After  any    `json:"after"`
+}
+
+// secretOptions are the feed options whose values should never be displayed.
+var secretOptions = map[string]struct{}{
+	"cookie":   {},
+	"password": {},
+}
+
+// IsSecret returns true if the option holds a credential, and its values should be masked.
+func (c OptionChange) IsSecret() bool {
+	_, ok := secretOptions[c.Field]
+	return ok
+}
+
+// Masked returns a copy of the change with the values replaced, if the option is secret.
+func (c OptionChange) Masked() OptionChange {
+	if !c.IsSecret() {
+		return c
+	}
+
+	if c.Before != nil {
+		c.Before = maskedValue
+	}
+	if c.After != nil {
+		c.After = maskedValue
+	}
+
+	return c
+}
+
+// String formats the change for display, e.g. `scraper_rules: "article" -> "div.content"`.
+// Secret values are always masked.
+func (c OptionChange) String() string {
+	masked := c.Masked()
+	return fmt.Sprintf(
+		"%s: %s -> %s", masked.Field, formatOptionValue(masked.Before), formatOptionValue(masked.After),
+	)
+}
+
+func formatOptionValue(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return "unset"
+	case string:
+		return strconv.Quote(v)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// optionValue is a named feed option value, used to compare options field by field.
+type optionValue struct {
+	field string
+	value any
+}
+
+// Changes returns the options set in the receiver (desired) which differ from current, in the
+// order they are defined in FeedOptions. Options not set in the receiver are ignored, matching
+// Equal.
+func (o FeedOptions) Changes(current FeedOptions) []OptionChange {
+	changes := []OptionChange{}
+
+	desiredValues := o.values()
+	currentValues := current.values()
+
+	for i, desired := range desiredValues {
+		if desired.value == nil || desired.value == currentValues[i].value {
+			continue
+		}
+
+		changes = append(changes, OptionChange{
+			Field:  desired.field,
+			Before: currentValues[i].value,
+			After:  desired.value,
+		})
+	}
+
+	return changes
+}
+
+// values returns the dereferenced value of every option, with nil for options that are not set.
+func (o FeedOptions) values() []optionValue {
+	return []optionValue{
+		{field: "crawler", value: boolValue(o.Crawler)},
+		{field: "username", value: stringValue(o.Username)},
+		{field: "password", value: stringValue(o.Password)},
+		{field: "user_agent", value: stringValue(o.UserAgent)},
+		{field: "cookie", value: stringValue(o.Cookie)},
+		{field: "disabled", value: boolValue(o.Disabled)},
+		{field: "ignore_http_cache", value: boolValue(o.IgnoreHTTPCache)},
+		{field: "fetch_via_proxy", value: boolValue(o.FetchViaProxy)},
+		{field: "allow_self_signed_certificates", value: boolValue(o.AllowSelfSignedCertificates)},
+		{field: "disable_http2", value: boolValue(o.DisableHTTP2)},
+		{field: "scraper_rules", value: stringValue(o.ScraperRules)},
+		{field: "rewrite_rules", value: stringValue(o.RewriteRules)},
+		{field: "blocklist_rules", value: stringValue(o.BlocklistRules)},
+		{field: "keeplist_rules", value: stringValue(o.KeeplistRules)},
+		{field: "hide_globally", value: boolValue(o.HideGlobally)},
+	}
+}
+
+func boolValue(b *bool) any {
+	if b == nil {
+		return nil
+	}
+	return *b
+}
+
+func stringValue(s *string) any {
+	if s == nil {
+		return nil
+	}
+	return *s
+}
@@ -0,0 +1,104 @@
+package diff_test
+
+import (
+	"testing"
+
+	"github.com/revett/miniflux-sync/diff"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFeedOptionsChanges(t *testing.T) {
+	t.Parallel()
+
+	enabled := true
+	disabled := false
+	article := "article"
+	content := "div.content"
+	secret := "secret"
+
+	tests := map[string]struct {
+		desired  diff.FeedOptions
+		current  diff.FeedOptions
+		expected []diff.OptionChange
+	}{
+		"NoOptions": {
+			desired:  diff.FeedOptions{},
+			current:  diff.FeedOptions{Crawler: &enabled},
+			expected: []diff.OptionChange{},
+		},
+		"Equal": {
+			desired:  diff.FeedOptions{ScraperRules: &article},
+			current:  diff.FeedOptions{ScraperRules: &article, Crawler: &enabled},
+			expected: []diff.OptionChange{},
+		},
+		"ChangedString": {
+			desired: diff.FeedOptions{ScraperRules: &content},
+			current: diff.FeedOptions{ScraperRules: &article},
+			expected: []diff.OptionChange{
+				{Field: "scraper_rules", Before: "article", After: "div.content"},
+			},
+		},
+		"NewStringAndChangedBool": {
+			desired: diff.FeedOptions{Crawler: &enabled, Password: &secret},
+			current: diff.FeedOptions{Crawler: &disabled},
+			expected: []diff.OptionChange{
+				{Field: "crawler", Before: false, After: true},
+				{Field: "password", Before: nil, After: "secret"},
+			},
+		},
+	}
+
+	for name, testCase := range tests {
+		tc := testCase
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			require.Equal(t, tc.expected, tc.desired.Changes(tc.current))
+		})
+	}
+}
+
+func TestOptionChangeIsSecret(t *testing.T) {
+	t.Parallel()
+
+	require.True(t, diff.OptionChange{Field: "password"}.IsSecret())
+	require.True(t, diff.OptionChange{Field: "cookie"}.IsSecret())
+	require.False(t, diff.OptionChange{Field: "scraper_rules"}.IsSecret())
+}
+
+func TestOptionChangeString(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		change   diff.OptionChange
+		expected string
+	}{
+		"String": {
+			change:   diff.OptionChange{Field: "scraper_rules", Before: "article", After: "div.content"},
+			expected: `scraper_rules: "article" -> "div.content"`,
+		},
+		"Bool": {
+			change:   diff.OptionChange{Field: "crawler", Before: false, After: true},
+			expected: "crawler: false -> true",
+		},
+		"Unset": {
+			change:   diff.OptionChange{Field: "user_agent", Before: nil, After: "Custom"},
+			expected: `user_agent: unset -> "Custom"`,
+		},
+		"Secret": {
+			change:   diff.OptionChange{Field: "password", Before: "old", After: "new"},
+			expected: `password: "********" -> "********"`,
+		},
+	}
+
+	for name, testCase := range tests {
+		tc := testCase
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			require.Equal(t, tc.expected, tc.change.String())
+		})
+	}
+}
@@ -0,0 +1,54 @@
+package diff
+
+import "slices"
+
+// IsApplied checks if the effect of an action is already present in the state, for example a
+// category to be created already exists.
+func (s State) IsApplied(action Action) bool {
+	switch action.Type {
+	case CreateCategory:
+		return s.CategoryExists(action.CategoryTitle)
+	case CreateFeed:
+		return s.FeedExists(action.FeedURL, action.CategoryTitle)
+	case UpdateFeed:
+		return s.FeedExists(action.FeedURL, action.CategoryTitle) &&
+			!needsUpdate(action.FeedOptions, s.GetFeedOptions(action.FeedURL))
+	case DeleteFeed:
+		return !s.FeedExists(action.FeedURL, action.CategoryTitle)
+	case DeleteCategory:
+		return !s.CategoryExists(action.CategoryTitle)
+	default:
+		return false
+	}
+}
+
+// Remaining returns the actions which are not done, keeping their order. Dependencies on actions
+// which are done are removed, as they are already satisfied.
+func Remaining(actions []Action, done func(Action) bool) []Action {
+	doneIDs := map[string]struct{}{}
+	for _, action := range actions {
+		if done(action) {
+			doneIDs[action.ID()] = struct{}{}
+		}
+	}
+
+	remaining := []Action{}
+
+	for _, action := range actions {
+		if _, ok := doneIDs[action.ID()]; ok {
+			continue
+		}
+
+		action.DependsOn = slices.DeleteFunc(slices.Clone(action.DependsOn), func(id string) bool {
+			_, ok := doneIDs[id]
+			return ok
+		})
+		if len(action.DependsOn) == 0 {
+			action.DependsOn = nil
+		}
+
+		remaining = append(remaining, action)
+	}
+
+	return remaining
+}
@@ -0,0 +1,128 @@
+package diff_test
+
+import (
+	"testing"
+
+	"github.com/revett/miniflux-sync/diff"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsApplied(t *testing.T) {
+	t.Parallel()
+
+	crawler := true
+	article := "article"
+	state := diff.State{
+		FeedURLsByCategoryTitle: map[string][]string{
+			"Tech": {"https://tech.com/feed"},
+		},
+		FeedsByCategoryTitle: map[string][]diff.Feed{
+			"Tech": {{URL: "https://tech.com/feed", Options: diff.FeedOptions{Crawler: &crawler}}},
+		},
+	}
+
+	tests := map[string]struct {
+		action   diff.Action
+		expected bool
+	}{
+		"CategoryCreated": {
+			action:   diff.Action{Type: diff.CreateCategory, CategoryTitle: "Tech"},
+			expected: true,
+		},
+		"CategoryNotCreated": {
+			action:   diff.Action{Type: diff.CreateCategory, CategoryTitle: "Music"},
+			expected: false,
+		},
+		"FeedCreated": {
+			action: diff.Action{
+				Type: diff.CreateFeed, CategoryTitle: "Tech", FeedURL: "https://tech.com/feed",
+			},
+			expected: true,
+		},
+		"FeedCreatedInOtherCategory": {
+			action: diff.Action{
+				Type: diff.CreateFeed, CategoryTitle: "Music", FeedURL: "https://tech.com/feed",
+			},
+			expected: false,
+		},
+		"FeedUpdated": {
+			action: diff.Action{
+				Type:          diff.UpdateFeed,
+				CategoryTitle: "Tech",
+				FeedURL:       "https://tech.com/feed",
+				FeedOptions:   diff.FeedOptions{Crawler: &crawler},
+			},
+			expected: true,
+		},
+		"FeedNotUpdated": {
+			action: diff.Action{
+				Type:          diff.UpdateFeed,
+				CategoryTitle: "Tech",
+				FeedURL:       "https://tech.com/feed",
+				FeedOptions:   diff.FeedOptions{ScraperRules: &article},
+			},
+			expected: false,
+		},
+		"FeedDeleted": {
+			action: diff.Action{
+				Type: diff.DeleteFeed, CategoryTitle: "Music", FeedURL: "https://music.com/feed",
+			},
+			expected: true,
+		},
+		"FeedNotDeleted": {
+			action: diff.Action{
+				Type: diff.DeleteFeed, CategoryTitle: "Tech", FeedURL: "https://tech.com/feed",
+			},
+			expected: false,
+		},
+		"CategoryDeleted": {
+			action:   diff.Action{Type: diff.DeleteCategory, CategoryTitle: "Music"},
+			expected: true,
+		},
+		"CategoryNotDeleted": {
+			action:   diff.Action{Type: diff.DeleteCategory, CategoryTitle: "Tech"},
+			expected: false,
+		},
+	}
+
+	for name, testCase := range tests {
+		tc := testCase
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			require.Equal(t, tc.expected, state.IsApplied(tc.action))
+		})
+	}
+}
+
+func TestRemaining(t *testing.T) {
+	t.Parallel()
+
+	actions := []diff.Action{
+		{Type: diff.CreateCategory, CategoryTitle: "Music"},
+		{
+			Type:          diff.CreateFeed,
+			CategoryTitle: "Music",
+			FeedURL:       "https://music.com/feed",
+			DependsOn:     []string{`["CreateCategory","Music",""]`, `["DeleteFeed","Old","https://music.com/feed"]`},
+		},
+		{Type: diff.DeleteFeed, CategoryTitle: "Old", FeedURL: "https://music.com/feed"},
+	}
+
+	remaining := diff.Remaining(actions, func(action diff.Action) bool {
+		return action.Type == diff.CreateCategory
+	})
+
+	require.Equal(t, []diff.Action{
+		{
+			Type:          diff.CreateFeed,
+			CategoryTitle: "Music",
+			FeedURL:       "https://music.com/feed",
+			DependsOn:     []string{`["DeleteFeed","Old","https://music.com/feed"]`},
+		},
+		{Type: diff.DeleteFeed, CategoryTitle: "Old", FeedURL: "https://music.com/feed"},
+	}, remaining)
+
+	// The original actions are not modified.
+	require.Len(t, actions[1].DependsOn, 2)
+}
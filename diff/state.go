@@ -1,6 +1,12 @@
 package diff
 
-import "slices"
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"slices"
+	"sort"
+)
 
 // State represents either the current remote state of Miniflux, or the intended local state of
 // Miniflux.
@@ -41,6 +47,33 @@ func (s State) FeedExists(feedURL string, categoryTitle string) bool {
 	return slices.Contains(feedURLs, feedURL)
 }
 
+// Fingerprint returns a stable hash of the state, covering every category, feed URL and feed
+// option. Two states with the same fingerprint will produce the same diff.
+func (s State) Fingerprint() string {
+	hash := sha256.New()
+
+	categoryTitles := s.CategoryTitles()
+	sort.Strings(categoryTitles)
+
+	feedsByCategory := s.GetFeedsByCategory()
+
+	for _, categoryTitle := range categoryTitles {
+		hash.Write([]byte("category\t" + categoryTitle + "\n"))
+
+		feeds := slices.Clone(feedsByCategory[categoryTitle])
+		sort.Slice(feeds, func(i int, j int) bool {
+			return feeds[i].URL < feeds[j].URL
+		})
+
+		for _, feed := range feeds {
+			options, _ := json.Marshal(feed.Options) //nolint:errchkjson
+			hash.Write([]byte("feed\t" + feed.URL + "\t" + string(options) + "\n"))
+		}
+	}
+
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
 // FeedURLs returns a list of all feed URLs in the state.
 func (s State) FeedURLs() []string {
 	feedURLs := []string{}
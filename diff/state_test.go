@@ -0,0 +1,88 @@
+package diff_test
+
+import (
+	"testing"
+
+	"github.com/revett/miniflux-sync/diff"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStateFingerprint(t *testing.T) {
+	t.Parallel()
+
+	crawler := true
+
+	base := diff.State{
+		FeedURLsByCategoryTitle: map[string][]string{
+			"Tech":  {"https://a.com/feed", "https://b.com/feed"},
+			"Music": {},
+		},
+	}
+
+	tests := map[string]struct {
+		state diff.State
+		equal bool
+	}{
+		"SameState": {
+			state: base,
+			equal: true,
+		},
+		"DifferentFeedOrder": {
+			state: diff.State{
+				FeedURLsByCategoryTitle: map[string][]string{
+					"Tech":  {"https://b.com/feed", "https://a.com/feed"},
+					"Music": {},
+				},
+			},
+			equal: true,
+		},
+		"MissingEmptyCategory": {
+			state: diff.State{
+				FeedURLsByCategoryTitle: map[string][]string{
+					"Tech": {"https://a.com/feed", "https://b.com/feed"},
+				},
+			},
+			equal: false,
+		},
+		"FeedInDifferentCategory": {
+			state: diff.State{
+				FeedURLsByCategoryTitle: map[string][]string{
+					"Tech":  {"https://a.com/feed"},
+					"Music": {"https://b.com/feed"},
+				},
+			},
+			equal: false,
+		},
+		"DifferentOptions": {
+			state: diff.State{
+				FeedURLsByCategoryTitle: map[string][]string{
+					"Tech":  {"https://a.com/feed", "https://b.com/feed"},
+					"Music": {},
+				},
+				FeedsByCategoryTitle: map[string][]diff.Feed{
+					"Tech": {
+						{URL: "https://a.com/feed", Options: diff.FeedOptions{Crawler: &crawler}},
+						{URL: "https://b.com/feed"},
+					},
+					"Music": {},
+				},
+			},
+			equal: false,
+		},
+	}
+
+	for name, testCase := range tests {
+		tc := testCase
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			if tc.equal {
+				require.Equal(t, base.Fingerprint(), tc.state.Fingerprint())
+				return
+			}
+
+			require.NotEqual(t, base.Fingerprint(), tc.state.Fingerprint())
+		})
+	}
+}
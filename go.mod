@@ -3,10 +3,12 @@ module github.com/revett/miniflux-sync
 go 1.22.4
 
 require (
+	github.com/fsnotify/fsnotify v1.9.0
 	github.com/pkg/errors v0.9.1
 	github.com/rs/zerolog v1.33.0
 	github.com/stretchr/testify v1.10.0
 	github.com/urfave/cli/v2 v2.27.2
+	golang.org/x/time v0.10.0
 	gopkg.in/yaml.v2 v2.4.0
 	miniflux.app/v2 v2.2.0
 )
@@ -0,0 +1,250 @@
+package journal
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/revett/miniflux-sync/diff"
+	"github.com/revett/miniflux-sync/log"
+)
+
+// Event is the type of a journal record.
+type Event string
+
+const (
+	// EventPlan is the first record of a journal, holding every action in the plan.
+	EventPlan Event = "plan"
+
+	// EventStart is recorded before an action is performed.
+	EventStart Event = "start"
+
+	// EventComplete is recorded after an action succeeds.
+	EventComplete Event = "complete"
+
+	// EventFail is recorded after an action fails.
+	EventFail Event = "fail"
+)
+
+// Status is the last known status of an action in a journal.
+type Status string
+
+const (
+	// StatusPending means the action was never started.
+	StatusPending Status = "pending"
+
+	// StatusStarted means the action was started, but its outcome was never recorded, so it may or
+	// may not have been applied.
+	StatusStarted Status = "started"
+
+	// StatusCompleted means the action succeeded.
+	StatusCompleted Status = "completed"
+
+	// StatusFailed means the action failed.
+	StatusFailed Status = "failed"
+)
+
+// record is a single line of a journal file.
+type record struct {
+	Event    Event         `json:"event"`
+	Time     time.Time     `json:"time"`
+	ActionID string        `json:"action_id,omitempty"`
+	Error    string        `json:"error,omitempty"`
+	Actions  []diff.Action `json:"actions,omitempty"`
+}
+
+// Journal is a write-ahead log of the actions performed by a sync, so an interrupted sync can be
+// resumed. Each record is written and synced to disk before the journal returns. A nil *Journal
+// records nothing, and it is safe for concurrent use.
+type Journal struct {
+	mu   sync.Mutex
+	file *os.File
+	path string
+}
+
+// Create creates a new journal at path, recording the actions of the plan. It fails if a journal
+// already exists at path, as that belongs to an interrupted sync.
+func Create(ctx context.Context, path string, actions []diff.Action) (*Journal, error) {
+	log.Info(ctx, "creating sync journal", log.Metadata{
+		"path": path,
+	})
+
+	// Actions can contain feed credentials, so keep the journal private.
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o600) //nolint:gosec,mnd
+	if os.IsExist(err) {
+		return nil, errors.Errorf(
+			`journal from an interrupted sync exists, resume it with "--resume" or delete it: "%s"`,
+			path,
+		)
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "creating journal file")
+	}
+
+	journal := &Journal{file: file, path: path}
+	if err := journal.write(record{Event: EventPlan, Actions: actions}); err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	return journal, nil
+}
+
+// Open opens an existing journal at path, so further records are appended to it.
+func Open(path string) (*Journal, error) {
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0o600) //nolint:gosec,mnd
+	if err != nil {
+		return nil, errors.Wrap(err, "opening journal file")
+	}
+
+	return &Journal{file: file, path: path}, nil
+}
+
+// Start records that an action is about to be performed.
+func (j *Journal) Start(action diff.Action) error {
+	return j.write(record{Event: EventStart, ActionID: action.ID()})
+}
+
+// Complete records that an action succeeded.
+func (j *Journal) Complete(action diff.Action) error {
+	return j.write(record{Event: EventComplete, ActionID: action.ID()})
+}
+
+// Fail records that an action failed.
+func (j *Journal) Fail(action diff.Action, actionErr error) error {
+	return j.write(record{Event: EventFail, ActionID: action.ID(), Error: actionErr.Error()})
+}
+
+// Close closes the journal file.
+func (j *Journal) Close() error {
+	if j == nil {
+		return nil
+	}
+
+	if err := j.file.Close(); err != nil {
+		return errors.Wrap(err, "closing journal file")
+	}
+
+	return nil
+}
+
+// Remove closes and deletes the journal, once the sync it records has finished.
+func (j *Journal) Remove() error {
+	if j == nil {
+		return nil
+	}
+
+	if err := j.Close(); err != nil {
+		return err
+	}
+
+	if err := os.Remove(j.path); err != nil {
+		return errors.Wrap(err, "removing journal file")
+	}
+
+	return nil
+}
+
+func (j *Journal) write(r record) error {
+	if j == nil {
+		return nil
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	r.Time = time.Now().UTC()
+
+	data, err := json.Marshal(r)
+	if err != nil {
+		return errors.Wrap(err, "marshalling journal record")
+	}
+
+	if _, err := j.file.Write(append(data, '\n')); err != nil {
+		return errors.Wrap(err, "writing journal record")
+	}
+
+	if err := j.file.Sync(); err != nil {
+		return errors.Wrap(err, "syncing journal file")
+	}
+
+	return nil
+}
+
+// Entry is an action from a journal, along with its last known status.
+type Entry struct {
+	Action diff.Action
+	Status Status
+}
+
+// Read reads the journal at path, returning every action in the plan with its last known status.
+func Read(ctx context.Context, path string) ([]Entry, error) {
+	log.Info(ctx, "reading sync journal", log.Metadata{
+		"path": path,
+	})
+
+	file, err := os.Open(path) //nolint:gosec
+	if err != nil {
+		return nil, errors.Wrap(err, "opening journal file")
+	}
+	defer file.Close()
+
+	var entries []Entry
+	indexByID := map[string]int{}
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(nil, 16*1024*1024) //nolint:mnd
+
+	for scanner.Scan() {
+		var r record
+		if err := json.Unmarshal(scanner.Bytes(), &r); err != nil {
+			// A record cut short by the interruption is ignored, as its write never completed.
+			log.Warn(ctx, "ignoring incomplete journal record")
+			continue
+		}
+
+		if r.Event == EventPlan {
+			if entries != nil {
+				return nil, errors.New("journal contains more than one plan")
+			}
+
+			entries = make([]Entry, 0, len(r.Actions))
+			for i, action := range r.Actions {
+				entries = append(entries, Entry{Action: action, Status: StatusPending})
+				indexByID[action.ID()] = i
+			}
+
+			continue
+		}
+
+		index, ok := indexByID[r.ActionID]
+		if !ok {
+			return nil, errors.Errorf(`journal records unknown action: "%s"`, r.ActionID)
+		}
+
+		switch r.Event {
+		case EventStart:
+			entries[index].Status = StatusStarted
+		case EventComplete:
+			entries[index].Status = StatusCompleted
+		case EventFail:
+			entries[index].Status = StatusFailed
+		default:
+			return nil, errors.Errorf(`unknown journal event: "%s"`, r.Event)
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, errors.Wrap(err, "reading journal file")
+	}
+
+	if entries == nil {
+		return nil, errors.New("journal does not contain a plan")
+	}
+
+	return entries, nil
+}
@@ -0,0 +1,121 @@
+package journal_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/revett/miniflux-sync/diff"
+	"github.com/revett/miniflux-sync/journal"
+	"github.com/revett/miniflux-sync/log"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRead(t *testing.T) {
+	t.Parallel()
+
+	actions := []diff.Action{
+		{Type: diff.CreateCategory, CategoryTitle: "Music"},
+		{Type: diff.CreateFeed, CategoryTitle: "Music", FeedURL: "https://music.com/feed"},
+		{Type: diff.DeleteFeed, CategoryTitle: "Old", FeedURL: "https://old.com/feed"},
+		{Type: diff.DeleteCategory, CategoryTitle: "Old"},
+	}
+
+	logger := log.New()
+	ctx := logger.WithContext(context.Background())
+	path := filepath.Join(t.TempDir(), "feeds.yml.journal")
+
+	j, err := journal.Create(ctx, path, actions)
+	require.NoError(t, err)
+
+	require.NoError(t, j.Start(actions[0]))
+	require.NoError(t, j.Complete(actions[0]))
+	require.NoError(t, j.Start(actions[1]))
+	require.NoError(t, j.Start(actions[2]))
+	require.NoError(t, j.Fail(actions[2], errors.New("boom")))
+	require.NoError(t, j.Close())
+
+	// Records appended after reopening the journal are read too.
+	j, err = journal.Open(path)
+	require.NoError(t, err)
+	require.NoError(t, j.Start(actions[1]))
+	require.NoError(t, j.Close())
+
+	entries, err := journal.Read(ctx, path)
+	require.NoError(t, err)
+	require.Equal(t, []journal.Entry{
+		{Action: actions[0], Status: journal.StatusCompleted},
+		{Action: actions[1], Status: journal.StatusStarted},
+		{Action: actions[2], Status: journal.StatusFailed},
+		{Action: actions[3], Status: journal.StatusPending},
+	}, entries)
+}
+
+func TestRead_IncompleteRecord(t *testing.T) {
+	t.Parallel()
+
+	actions := []diff.Action{{Type: diff.CreateCategory, CategoryTitle: "Music"}}
+
+	logger := log.New()
+	ctx := logger.WithContext(context.Background())
+	path := filepath.Join(t.TempDir(), "feeds.yml.journal")
+
+	j, err := journal.Create(ctx, path, actions)
+	require.NoError(t, err)
+	require.NoError(t, j.Start(actions[0]))
+	require.NoError(t, j.Close())
+
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0o600)
+	require.NoError(t, err)
+	_, err = file.WriteString(`{"event":"comp`)
+	require.NoError(t, err)
+	require.NoError(t, file.Close())
+
+	entries, err := journal.Read(ctx, path)
+	require.NoError(t, err)
+	require.Equal(t, []journal.Entry{{Action: actions[0], Status: journal.StatusStarted}}, entries)
+}
+
+func TestCreate_ExistingJournal(t *testing.T) {
+	t.Parallel()
+
+	logger := log.New()
+	ctx := logger.WithContext(context.Background())
+	path := filepath.Join(t.TempDir(), "feeds.yml.journal")
+
+	j, err := journal.Create(ctx, path, []diff.Action{})
+	require.NoError(t, err)
+	require.NoError(t, j.Close())
+
+	_, err = journal.Create(ctx, path, []diff.Action{})
+	require.ErrorContains(t, err, "interrupted sync")
+}
+
+func TestRemove(t *testing.T) {
+	t.Parallel()
+
+	logger := log.New()
+	ctx := logger.WithContext(context.Background())
+	path := filepath.Join(t.TempDir(), "feeds.yml.journal")
+
+	j, err := journal.Create(ctx, path, []diff.Action{})
+	require.NoError(t, err)
+	require.NoError(t, j.Remove())
+
+	_, err = os.Stat(path)
+	require.True(t, os.IsNotExist(err))
+}
+
+func TestNilJournal(t *testing.T) {
+	t.Parallel()
+
+	var j *journal.Journal
+
+	action := diff.Action{Type: diff.CreateCategory, CategoryTitle: "Music"}
+	require.NoError(t, j.Start(action))
+	require.NoError(t, j.Complete(action))
+	require.NoError(t, j.Fail(action, errors.New("boom")))
+	require.NoError(t, j.Remove())
+}
@@ -2,6 +2,7 @@ package log
 
 import (
 	"context"
+	"io"
 	"os"
 	"time"
 
@@ -12,10 +13,15 @@ import (
 // Metadata is a map of string to any, which is used to add metadata fields to a log event.
 type Metadata map[string]any
 
-// New returns a new zerolog.Logger, using a ConsoleWriter.
+// New returns a new zerolog.Logger, using a ConsoleWriter which writes to stdout.
 func New() zerolog.Logger {
+	return NewWithWriter(os.Stdout)
+}
+
+// NewWithWriter returns a new zerolog.Logger, using a ConsoleWriter which writes to w.
+func NewWithWriter(w io.Writer) zerolog.Logger {
 	writer := zerolog.ConsoleWriter{
-		Out:        os.Stdout,
+		Out:        w,
 		TimeFormat: "2006-01-02T15:04:05.0000",
 	}
 
@@ -4,6 +4,8 @@ import (
 	"context"
 	_ "embed"
 	"os"
+	"os/signal"
+	"syscall"
 
 	"github.com/revett/miniflux-sync/cmd"
 	"github.com/revett/miniflux-sync/config"
@@ -21,6 +23,7 @@ func main() {
 	// Create logger, and attach to context.
 	zerolog.Logger = log.New()
 	ctx = zerolog.With().Logger().WithContext(ctx)
+	ctx = cancelOnSignal(ctx)
 
 	cfg := config.New(version)
 
@@ -36,3 +39,25 @@ func main() {
 		log.Fatal(ctx, err)
 	}
 }
+
+// cancelOnSignal returns a context which is cancelled on the first SIGINT or SIGTERM, so the run
+// stops once the actions in flight have finished. A second signal exits immediately.
+func cancelOnSignal(ctx context.Context) context.Context {
+	ctx, cancel := context.WithCancel(ctx)
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+
+	go func() {
+		sig := <-signals
+		signal.Stop(signals)
+
+		log.Warn(ctx, "stopping after actions in flight, signal again to exit immediately", log.Metadata{
+			"signal": sig.String(),
+		})
+
+		cancel()
+	}()
+
+	return ctx
+}
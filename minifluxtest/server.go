@@ -0,0 +1,514 @@
+// Package minifluxtest provides an in-process fake Miniflux server for tests, serving the subset of
+// the Miniflux REST API used by miniflux-sync from in-memory state.
+package minifluxtest
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	miniflux "miniflux.app/v2/client"
+)
+
+// The credentials accepted by the server, either the API key or the username and password. Requests
+// with other credentials are unauthorized.
+const (
+	APIKey   = "minifluxtest-api-key"
+	Username = "minifluxtest"
+	Password = "minifluxtest-password"
+)
+
+// Fault is an error injected into the responses of the server.
+type Fault struct {
+	// Method is the HTTP method of the requests to fail, or empty for any method.
+	Method string
+	// Path is the prefix of the paths of the requests to fail, e.g. "/v1/feeds".
+	Path string
+	// StatusCode is the status code of the failed responses.
+	StatusCode int
+	// Message is the error message of the failed responses.
+	Message string
+	// Times is the number of requests to fail, or zero to fail every request.
+	Times int
+	// RetryAfter is the value of the Retry-After header of the failed responses, if set.
+	RetryAfter string
+}
+
+// Server is a fake Miniflux server. It is safe for concurrent use.
+type Server struct {
+	// URL is the base URL of the server, for use as the Miniflux endpoint.
+	URL string
+
+	server *httptest.Server
+
+	mu         sync.Mutex
+	categories []miniflux.Category
+	feeds      []miniflux.Feed
+	nextID     int64
+	faults     []*Fault
+	latency    time.Duration
+	requests   []string
+	version    string
+}
+
+// Version is the Miniflux version reported by the server, unless changed with SetVersion.
+const Version = "2.2.0"
+
+// NewServer starts a new Server with no feeds or categories. It should be closed once finished.
+func NewServer() *Server {
+	s := &Server{version: Version}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /healthcheck", s.healthcheck)
+	mux.HandleFunc("GET /v1/me", s.me)
+	mux.HandleFunc("GET /v1/version", s.apiVersion)
+	mux.HandleFunc("GET /version", s.legacyVersion)
+	mux.HandleFunc("GET /v1/categories", s.listCategories)
+	mux.HandleFunc("POST /v1/categories", s.createCategory)
+	mux.HandleFunc("DELETE /v1/categories/{id}", s.deleteCategory)
+	mux.HandleFunc("GET /v1/feeds", s.listFeeds)
+	mux.HandleFunc("POST /v1/feeds", s.createFeed)
+	mux.HandleFunc("GET /v1/feeds/{id}", s.getFeed)
+	mux.HandleFunc("PUT /v1/feeds/{id}", s.updateFeed)
+	mux.HandleFunc("DELETE /v1/feeds/{id}", s.deleteFeed)
+	mux.HandleFunc("POST /v1/discover", s.discover)
+
+	s.server = httptest.NewServer(s.middleware(mux))
+	s.URL = s.server.URL
+
+	return s
+}
+
+// Close shuts down the server.
+func (s *Server) Close() {
+	s.server.Close()
+}
+
+// AddCategory adds a category to the server, returning it with its ID.
+func (s *Server) AddCategory(title string) miniflux.Category {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.addCategory(title)
+}
+
+// AddFeed adds a feed to the category with the given title, creating the category if needed. The
+// feed is returned with its ID and category.
+func (s *Server) AddFeed(categoryTitle string, feed miniflux.Feed) miniflux.Feed {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	index := slices.IndexFunc(s.categories, func(c miniflux.Category) bool {
+		return c.Title == categoryTitle
+	})
+
+	category := miniflux.Category{}
+	if index == -1 {
+		category = s.addCategory(categoryTitle)
+	} else {
+		category = s.categories[index]
+	}
+
+	s.nextID++
+	feed.ID = s.nextID
+	feed.Category = &category
+	s.feeds = append(s.feeds, feed)
+
+	return feed
+}
+
+// Categories returns a copy of the categories on the server.
+func (s *Server) Categories() []miniflux.Category {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return slices.Clone(s.categories)
+}
+
+// Feeds returns a copy of the feeds on the server.
+func (s *Server) Feeds() []miniflux.Feed {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return slices.Clone(s.feeds)
+}
+
+// InjectFault makes matching requests fail. Faults are matched in the order they were injected.
+func (s *Server) InjectFault(fault Fault) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.faults = append(s.faults, &fault)
+}
+
+// SetLatency delays every response by latency.
+func (s *Server) SetLatency(latency time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.latency = latency
+}
+
+// SetVersion sets the Miniflux version reported by the server. To act as a server older than
+// 2.0.49, inject a 404 fault for "/v1/version", leaving only "/version".
+func (s *Server) SetVersion(version string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.version = version
+}
+
+// Requests returns the requests received by the server, such as "POST /v1/feeds".
+func (s *Server) Requests() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return slices.Clone(s.requests)
+}
+
+// middleware records requests, checks the credentials, and applies latency and faults before serving
+// a request.
+func (s *Server) middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		s.requests = append(s.requests, r.Method+" "+r.URL.Path)
+		latency := s.latency
+		fault := s.matchFault(r)
+		s.mu.Unlock()
+
+		if latency > 0 {
+			select {
+			case <-r.Context().Done():
+				return
+			case <-time.After(latency):
+			}
+		}
+
+		if !authorized(r) && r.URL.Path != "/healthcheck" && r.URL.Path != "/version" {
+			writeError(w, http.StatusUnauthorized, "access unauthorized")
+			return
+		}
+
+		if fault != nil {
+			if fault.RetryAfter != "" {
+				w.Header().Set("Retry-After", fault.RetryAfter)
+			}
+
+			writeError(w, fault.StatusCode, fault.Message)
+
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// matchFault returns the first fault matching r, counting it against the fault's Times.
+func (s *Server) matchFault(r *http.Request) *Fault {
+	for i, fault := range s.faults {
+		if fault.Method != "" && fault.Method != r.Method {
+			continue
+		}
+
+		if !strings.HasPrefix(r.URL.Path, fault.Path) {
+			continue
+		}
+
+		if fault.Times > 0 {
+			fault.Times--
+			if fault.Times == 0 {
+				s.faults = slices.Delete(s.faults, i, i+1)
+			}
+		}
+
+		return fault
+	}
+
+	return nil
+}
+
+func (s *Server) healthcheck(w http.ResponseWriter, _ *http.Request) {
+	_, _ = w.Write([]byte("OK"))
+}
+
+func (s *Server) me(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, miniflux.User{ID: 1, Username: Username, IsAdmin: true})
+}
+
+func (s *Server) apiVersion(w http.ResponseWriter, _ *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	writeJSON(w, http.StatusOK, miniflux.VersionResponse{Version: s.version})
+}
+
+func (s *Server) legacyVersion(w http.ResponseWriter, _ *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, _ = w.Write([]byte(s.version))
+}
+
+func (s *Server) listCategories(w http.ResponseWriter, _ *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	writeJSON(w, http.StatusOK, s.categories)
+}
+
+func (s *Server) createCategory(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Title string `json:"title"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Title == "" {
+		writeError(w, http.StatusBadRequest, "invalid category")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if slices.ContainsFunc(s.categories, func(c miniflux.Category) bool {
+		return c.Title == req.Title
+	}) {
+		writeError(w, http.StatusBadRequest, "This category already exists.")
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, s.addCategory(req.Title))
+}
+
+func (s *Server) deleteCategory(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	index := slices.IndexFunc(s.categories, func(c miniflux.Category) bool { return c.ID == id })
+	if index == -1 {
+		writeError(w, http.StatusNotFound, "category not found")
+		return
+	}
+
+	s.categories = slices.Delete(s.categories, index, index+1)
+
+	// Miniflux deletes the feeds in a category along with it.
+	s.feeds = slices.DeleteFunc(s.feeds, func(f miniflux.Feed) bool { return f.Category.ID == id })
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) listFeeds(w http.ResponseWriter, _ *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	writeJSON(w, http.StatusOK, s.feeds)
+}
+
+func (s *Server) getFeed(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	index := slices.IndexFunc(s.feeds, func(f miniflux.Feed) bool { return f.ID == id })
+	if index == -1 {
+		writeError(w, http.StatusNotFound, "feed not found")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, s.feeds[index])
+}
+
+func (s *Server) createFeed(w http.ResponseWriter, r *http.Request) {
+	var req miniflux.FeedCreationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.FeedURL == "" {
+		writeError(w, http.StatusBadRequest, "invalid feed")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	index := slices.IndexFunc(s.categories, func(c miniflux.Category) bool {
+		return c.ID == req.CategoryID
+	})
+	if index == -1 {
+		writeError(w, http.StatusBadRequest, "This category does not exist or does not belong to this user.")
+		return
+	}
+
+	if slices.ContainsFunc(s.feeds, func(f miniflux.Feed) bool { return f.FeedURL == req.FeedURL }) {
+		writeError(w, http.StatusBadRequest, "This feed already exists.")
+		return
+	}
+
+	category := s.categories[index]
+
+	s.nextID++
+	s.feeds = append(s.feeds, miniflux.Feed{
+		ID:                          s.nextID,
+		FeedURL:                     req.FeedURL,
+		Category:                    &category,
+		UserAgent:                   req.UserAgent,
+		Cookie:                      req.Cookie,
+		Username:                    req.Username,
+		Password:                    req.Password,
+		Crawler:                     req.Crawler,
+		Disabled:                    req.Disabled,
+		IgnoreHTTPCache:             req.IgnoreHTTPCache,
+		AllowSelfSignedCertificates: req.AllowSelfSignedCertificates,
+		FetchViaProxy:               req.FetchViaProxy,
+		ScraperRules:                req.ScraperRules,
+		RewriteRules:                req.RewriteRules,
+		BlocklistRules:              req.BlocklistRules,
+		KeeplistRules:               req.KeeplistRules,
+		HideGlobally:                req.HideGlobally,
+		DisableHTTP2:                req.DisableHTTP2,
+	})
+
+	writeJSON(w, http.StatusCreated, map[string]int64{"feed_id": s.nextID})
+}
+
+func (s *Server) updateFeed(w http.ResponseWriter, r *http.Request) { //nolint:cyclop
+	id, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+
+	var req miniflux.FeedModificationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid feed")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	index := slices.IndexFunc(s.feeds, func(f miniflux.Feed) bool { return f.ID == id })
+	if index == -1 {
+		writeError(w, http.StatusNotFound, "feed not found")
+		return
+	}
+
+	feed := &s.feeds[index]
+
+	setString(&feed.ScraperRules, req.ScraperRules)
+	setString(&feed.RewriteRules, req.RewriteRules)
+	setString(&feed.BlocklistRules, req.BlocklistRules)
+	setString(&feed.KeeplistRules, req.KeeplistRules)
+	setString(&feed.UserAgent, req.UserAgent)
+	setString(&feed.Cookie, req.Cookie)
+	setString(&feed.Username, req.Username)
+	setString(&feed.Password, req.Password)
+	setBool(&feed.Crawler, req.Crawler)
+	setBool(&feed.Disabled, req.Disabled)
+	setBool(&feed.IgnoreHTTPCache, req.IgnoreHTTPCache)
+	setBool(&feed.AllowSelfSignedCertificates, req.AllowSelfSignedCertificates)
+	setBool(&feed.FetchViaProxy, req.FetchViaProxy)
+	setBool(&feed.HideGlobally, req.HideGlobally)
+	setBool(&feed.DisableHTTP2, req.DisableHTTP2)
+
+	writeJSON(w, http.StatusCreated, feed)
+}
+
+func (s *Server) deleteFeed(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	index := slices.IndexFunc(s.feeds, func(f miniflux.Feed) bool { return f.ID == id })
+	if index == -1 {
+		writeError(w, http.StatusNotFound, "feed not found")
+		return
+	}
+
+	s.feeds = slices.Delete(s.feeds, index, index+1)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// discover returns the requested URL as the only subscription found, as the fake server does not
+// fetch anything.
+func (s *Server) discover(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		URL string `json:"url"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.URL == "" {
+		writeError(w, http.StatusBadRequest, "invalid url")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, miniflux.Subscriptions{
+		{Title: req.URL, URL: req.URL, Type: "rss"},
+	})
+}
+
+// addCategory adds a category. The caller must hold s.mu.
+func (s *Server) addCategory(title string) miniflux.Category {
+	s.nextID++
+	category := miniflux.Category{ID: s.nextID, Title: title}
+	s.categories = append(s.categories, category)
+
+	return category
+}
+
+// authorized reports whether the request has the API key, or the username and password.
+func authorized(r *http.Request) bool {
+	if r.Header.Get("X-Auth-Token") == APIKey {
+		return true
+	}
+
+	username, password, ok := r.BasicAuth()
+
+	return ok && username == Username && password == Password
+}
+
+func pathID(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid id")
+		return 0, false
+	}
+
+	return id, true
+}
+
+func setString(field *string, value *string) {
+	if value != nil {
+		*field = *value
+	}
+}
+
+func setBool(field *bool, value *bool) {
+	if value != nil {
+		*field = *value
+	}
+}
+
+func writeJSON(w http.ResponseWriter, statusCode int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, statusCode int, message string) {
+	writeJSON(w, statusCode, map[string]string{"error_message": message})
+}
@@ -0,0 +1,95 @@
+package minifluxtest
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	miniflux "miniflux.app/v2/client"
+)
+
+func TestServer(t *testing.T) {
+	t.Parallel()
+
+	server := NewServer()
+	defer server.Close()
+
+	client := miniflux.New(server.URL, APIKey)
+	require.NoError(t, client.Healthcheck())
+
+	user, err := client.Me()
+	require.NoError(t, err)
+	require.Equal(t, Username, user.Username)
+
+	version, err := client.Version()
+	require.NoError(t, err)
+	require.Equal(t, Version, version.Version)
+
+	category, err := client.CreateCategory("news")
+	require.NoError(t, err)
+
+	_, err = client.CreateCategory("news")
+	require.ErrorIs(t, err, miniflux.ErrBadRequest)
+
+	feedID, err := client.CreateFeed(&miniflux.FeedCreationRequest{
+		FeedURL:    "https://example.com/feed.xml",
+		CategoryID: category.ID,
+	})
+	require.NoError(t, err)
+
+	crawler := true
+	feed, err := client.UpdateFeed(feedID, &miniflux.FeedModificationRequest{Crawler: &crawler})
+	require.NoError(t, err)
+	require.True(t, feed.Crawler)
+
+	subscriptions, err := client.Discover("https://example.com/feed.xml")
+	require.NoError(t, err)
+	require.Len(t, subscriptions, 1)
+
+	require.NoError(t, client.DeleteCategory(category.ID))
+	require.Empty(t, server.Categories())
+	require.Empty(t, server.Feeds())
+
+	_, err = miniflux.New(server.URL, Username, Password).Feeds()
+	require.NoError(t, err)
+
+	_, err = miniflux.New(server.URL, "wrong").Feeds()
+	require.ErrorIs(t, err, miniflux.ErrNotAuthorized)
+
+	_, err = miniflux.New(server.URL, Username, "wrong").Feeds()
+	require.ErrorIs(t, err, miniflux.ErrNotAuthorized)
+}
+
+func TestServer_InjectFault(t *testing.T) {
+	t.Parallel()
+
+	server := NewServer()
+	defer server.Close()
+
+	server.InjectFault(Fault{
+		Method:     http.MethodGet,
+		Path:       "/v1/feeds",
+		StatusCode: http.StatusServiceUnavailable,
+		Times:      2, //nolint:mnd
+	})
+
+	client := miniflux.New(server.URL, APIKey)
+
+	_, err := client.Categories()
+	require.NoError(t, err)
+
+	for range 2 {
+		_, err = client.Feeds()
+		require.ErrorContains(t, err, "status code=503")
+	}
+
+	_, err = client.Feeds()
+	require.NoError(t, err)
+
+	require.Equal(t, []string{
+		"GET /v1/categories",
+		"GET /v1/feeds",
+		"GET /v1/feeds",
+		"GET /v1/feeds",
+	}, server.Requests())
+}
@@ -75,6 +75,16 @@ func (f *feedEntry) UnmarshalYAML(unmarshal func(any) error) error {
 	return nil
 }
 
+// identityKey is the reserved top-level key which declares the Miniflux account a file is for, so it
+// cannot be used as a category title.
+const identityKey = "_miniflux"
+
+// document is the YAML file, holding the feeds of each category along with the identity.
+type document struct {
+	Identity   map[string]string      `yaml:"_miniflux"`
+	Categories map[string][]feedEntry `yaml:",inline"`
+}
+
 // Parse reads a YAML file to a diff.State struct.
 func Parse(ctx context.Context, path string) (*diff.State, error) {
 	log.Info(ctx, "reading data from yaml file")
@@ -85,8 +95,8 @@ func Parse(ctx context.Context, path string) (*diff.State, error) {
 		return nil, errors.Wrap(err, "reading data from file")
 	}
 
-	var rawData map[string][]feedEntry
-	if err := yaml.Unmarshal(data, &rawData); err != nil {
+	var doc document
+	if err := yaml.Unmarshal(data, &doc); err != nil {
 		return nil, errors.Wrap(err, "unmarshalling data")
 	}
 
@@ -95,7 +105,7 @@ func Parse(ctx context.Context, path string) (*diff.State, error) {
 		FeedsByCategoryTitle:    map[string][]diff.Feed{},
 	}
 
-	for category, entries := range rawData {
+	for category, entries := range doc.Categories {
 		for _, entry := range entries {
 			state.FeedURLsByCategoryTitle[category] = append(
 				state.FeedURLsByCategoryTitle[category], entry.URL)
@@ -115,6 +125,51 @@ func Parse(ctx context.Context, path string) (*diff.State, error) {
 	return &state, nil
 }
 
+// Identity reads the identity declared under the reserved "_miniflux" key of a YAML file, returning
+// nil if there is none.
+func Identity(ctx context.Context, path string) (*diff.Identity, error) {
+	data, err := os.ReadFile(path) //nolint:gosec
+	if err != nil {
+		return nil, errors.Wrap(err, "reading data from file")
+	}
+
+	var doc document
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, errors.Wrap(err, "unmarshalling data")
+	}
+
+	if doc.Identity == nil {
+		return nil, nil //nolint:nilnil
+	}
+
+	identity := diff.Identity{}
+
+	// Unknown keys are rejected, as a misspelt key would silently disable the check.
+	for key, value := range doc.Identity {
+		switch key {
+		case "username":
+			identity.Username = value
+		case "endpoint":
+			identity.Endpoint = value
+		default:
+			return nil, errors.Errorf(
+				`unknown key "%s" under "%s", expected "username" or "endpoint"`, key, identityKey,
+			)
+		}
+	}
+
+	if identity.Username == "" && identity.Endpoint == "" {
+		return nil, errors.Errorf(`"%s" must set "username" or "endpoint"`, identityKey)
+	}
+
+	log.Info(ctx, "data file declares miniflux identity", log.Metadata{
+		"username": identity.Username,
+		"endpoint": identity.Endpoint,
+	})
+
+	return &identity, nil
+}
+
 func validateDuplicateFeedURLs(state *diff.State) error {
 	feedURLSet := make(map[string]struct{})
 
@@ -6,6 +6,7 @@ import (
 	"path/filepath"
 	"testing"
 
+	"github.com/revett/miniflux-sync/diff"
 	"github.com/revett/miniflux-sync/log"
 	"github.com/stretchr/testify/require"
 )
@@ -165,3 +166,78 @@ func TestParse_MissingURL(t *testing.T) {
 	require.Error(t, err)
 	require.Contains(t, err.Error(), "url")
 }
+
+func TestIdentity(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		yaml     string
+		expected *diff.Identity
+		err      string
+	}{
+		"None": {
+			yaml: "Tech:\n  - https://example.com/feed.xml\n",
+		},
+		"UsernameAndEndpoint": {
+			yaml: "_miniflux:\n  username: alice\n  endpoint: https://miniflux.example.com\n" +
+				"Tech:\n  - https://example.com/feed.xml\n",
+			expected: &diff.Identity{Username: "alice", Endpoint: "https://miniflux.example.com"},
+		},
+		"UsernameOnly": {
+			yaml:     "_miniflux:\n  username: alice\n",
+			expected: &diff.Identity{Username: "alice"},
+		},
+		"UnknownKey": {
+			yaml: "_miniflux:\n  user: alice\n",
+			err:  `unknown key "user" under "_miniflux", expected "username" or "endpoint"`,
+		},
+		"Empty": {
+			yaml: "_miniflux:\n  username: \"\"\n",
+			err:  `"_miniflux" must set "username" or "endpoint"`,
+		},
+	}
+
+	for name, testCase := range tests {
+		tc := testCase
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			tmpFile := filepath.Join(t.TempDir(), "feeds.yml")
+			require.NoError(t, os.WriteFile(tmpFile, []byte(tc.yaml), 0o600))
+
+			logger := log.New()
+			ctx := logger.WithContext(context.Background())
+
+			identity, err := Identity(ctx, tmpFile)
+			if tc.err != "" {
+				require.EqualError(t, err, tc.err)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, identity)
+		})
+	}
+}
+
+func TestParse_IgnoresIdentity(t *testing.T) {
+	t.Parallel()
+
+	yaml := `_miniflux:
+  username: alice
+Tech:
+  - https://example.com/feed.xml`
+
+	tmpFile := filepath.Join(t.TempDir(), "feeds.yml")
+	require.NoError(t, os.WriteFile(tmpFile, []byte(yaml), 0o600))
+
+	logger := log.New()
+	ctx := logger.WithContext(context.Background())
+
+	state, err := Parse(ctx, tmpFile)
+	require.NoError(t, err)
+	require.Equal(t, map[string][]string{
+		"Tech": {"https://example.com/feed.xml"},
+	}, state.FeedURLsByCategoryTitle)
+}
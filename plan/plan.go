@@ -0,0 +1,85 @@
+package plan
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+
+	"github.com/pkg/errors"
+	"github.com/revett/miniflux-sync/diff"
+	"github.com/revett/miniflux-sync/log"
+)
+
+// Version is the current version of the plan file format. Version 2 added action dependencies,
+// without which actions cannot be safely ordered.
+const Version = 2
+
+// ErrStaleRemoteState is returned when the remote state has changed since a plan was calculated.
+var ErrStaleRemoteState = errors.New("remote state has changed since plan was calculated")
+
+// Plan is a saved list of actions, along with a fingerprint of the remote state that they were
+// calculated against. The identity declared by the data file is kept, so apply checks the account.
+type Plan struct {
+	Version           int            `json:"version"`
+	RemoteFingerprint string         `json:"remote_fingerprint"`
+	Identity          *diff.Identity `json:"identity,omitempty"`
+	Actions           []diff.Action  `json:"actions"`
+}
+
+// New is a convenience function for creating a new Plan.
+func New(actions []diff.Action, remote *diff.State) *Plan {
+	return &Plan{
+		Version:           Version,
+		RemoteFingerprint: remote.Fingerprint(),
+		Actions:           actions,
+	}
+}
+
+// Verify checks that the remote state matches the state the plan was calculated against.
+func (p *Plan) Verify(remote *diff.State) error {
+	if p.RemoteFingerprint != remote.Fingerprint() {
+		return ErrStaleRemoteState
+	}
+
+	return nil
+}
+
+// Write writes the plan to a JSON file.
+func Write(ctx context.Context, path string, p *Plan) error {
+	log.Info(ctx, "writing plan to file")
+	log.Info(ctx, path)
+
+	data, err := json.MarshalIndent(p, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "marshalling plan to json")
+	}
+
+	// Plans can contain feed credentials, so keep them private.
+	if err := os.WriteFile(path, data, 0o600); err != nil { //nolint:mnd
+		return errors.Wrap(err, "writing plan to file")
+	}
+
+	return nil
+}
+
+// Read reads a plan from a JSON file.
+func Read(ctx context.Context, path string) (*Plan, error) {
+	log.Info(ctx, "reading plan from file")
+	log.Info(ctx, path)
+
+	data, err := os.ReadFile(path) //nolint:gosec
+	if err != nil {
+		return nil, errors.Wrap(err, "reading plan from file")
+	}
+
+	var p Plan
+	if err := json.Unmarshal(data, &p); err != nil {
+		return nil, errors.Wrap(err, "unmarshalling plan")
+	}
+
+	if p.Version != Version {
+		return nil, errors.Errorf(`unsupported plan version: "%d"`, p.Version)
+	}
+
+	return &p, nil
+}
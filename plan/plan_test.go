@@ -0,0 +1,74 @@
+package plan_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/revett/miniflux-sync/diff"
+	"github.com/revett/miniflux-sync/log"
+	"github.com/revett/miniflux-sync/plan"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteRead(t *testing.T) {
+	t.Parallel()
+
+	crawler := true
+	remote := &diff.State{
+		FeedURLsByCategoryTitle: map[string][]string{
+			"Tech": {"https://tech.com/feed"},
+		},
+	}
+	actions := []diff.Action{
+		{
+			Type:          diff.CreateFeed,
+			CategoryTitle: "Tech",
+			FeedURL:       "https://music.com/feed",
+			FeedOptions:   diff.FeedOptions{Crawler: &crawler},
+		},
+	}
+
+	logger := log.New()
+	ctx := logger.WithContext(context.Background())
+	path := filepath.Join(t.TempDir(), "plan.json")
+
+	require.NoError(t, plan.Write(ctx, path, plan.New(actions, remote)))
+
+	p, err := plan.Read(ctx, path)
+	require.NoError(t, err)
+	require.Equal(t, actions, p.Actions)
+	require.NoError(t, p.Verify(remote))
+}
+
+func TestVerify_StaleRemoteState(t *testing.T) {
+	t.Parallel()
+
+	remote := &diff.State{
+		FeedURLsByCategoryTitle: map[string][]string{
+			"Tech": {"https://tech.com/feed"},
+		},
+	}
+	p := plan.New([]diff.Action{}, remote)
+
+	remote.FeedURLsByCategoryTitle["Tech"] = append(
+		remote.FeedURLsByCategoryTitle["Tech"], "https://music.com/feed",
+	)
+
+	require.ErrorIs(t, p.Verify(remote), plan.ErrStaleRemoteState)
+}
+
+func TestRead_UnsupportedVersion(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "plan.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"version": 99, "actions": []}`), 0o600))
+
+	logger := log.New()
+	ctx := logger.WithContext(context.Background())
+
+	_, err := plan.Read(ctx, path)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "version")
+}
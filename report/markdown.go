@@ -0,0 +1,199 @@
+package report
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/revett/miniflux-sync/diff"
+)
+
+// categoryLabel is displayed in place of a feed URL for category actions.
+const categoryLabel = "_(category)_"
+
+// markdownGroups holds the actions of a report, grouped by how they are displayed.
+type markdownGroups struct {
+	created []Action
+	moved   []move
+	updated []Action
+	deleted []Action
+}
+
+// move is a feed which is deleted from one category and created in another.
+type move struct {
+	FeedURL      string
+	FromCategory string
+	ToCategory   string
+}
+
+// WriteMarkdown writes the report as Markdown, with a table for each group of changes, suitable
+// for pull request comments and GitHub job summaries.
+func (r *Report) WriteMarkdown(w io.Writer) error {
+	var b strings.Builder
+
+	b.WriteString("## miniflux-sync plan\n\n")
+	fmt.Fprintf(
+		&b, "Local: %d feeds in %d categories. Remote: %d feeds in %d categories.\n\n",
+		r.Local.Feeds, r.Local.Categories, r.Remote.Feeds, r.Remote.Categories,
+	)
+
+	if !r.Summary.RequiresChanges {
+		b.WriteString("No changes. Miniflux matches the local feeds.\n")
+		return writeString(w, b.String())
+	}
+
+	groups := r.groupActions()
+
+	fmt.Fprintf(
+		&b, "**%d to create, %d to move, %d to update, %d to delete.**\n",
+		len(groups.created), len(groups.moved), len(groups.updated), len(groups.deleted),
+	)
+
+	if len(groups.created) > 0 {
+		b.WriteString("\n### Created\n\n| Category | Feed | Options |\n| --- | --- | --- |\n")
+		for _, action := range groups.created {
+			fmt.Fprintf(
+				&b, "| %s | %s | %s |\n",
+				markdownCell(action.CategoryTitle), feedCell(action), changesCell(action.Changes),
+			)
+		}
+	}
+
+	if len(groups.moved) > 0 {
+		b.WriteString("\n### Moved\n\n| Feed | From | To |\n| --- | --- | --- |\n")
+		for _, m := range groups.moved {
+			fmt.Fprintf(
+				&b, "| %s | %s | %s |\n",
+				markdownCell(m.FeedURL), markdownCell(m.FromCategory), markdownCell(m.ToCategory),
+			)
+		}
+	}
+
+	if len(groups.updated) > 0 {
+		b.WriteString("\n### Updated\n\n| Category | Feed | Changes |\n| --- | --- | --- |\n")
+		for _, action := range groups.updated {
+			fmt.Fprintf(
+				&b, "| %s | %s | %s |\n",
+				markdownCell(action.CategoryTitle), feedCell(action), changesCell(action.Changes),
+			)
+		}
+	}
+
+	if len(groups.deleted) > 0 {
+		b.WriteString("\n### Deleted\n\n| Category | Feed |\n| --- | --- |\n")
+		for _, action := range groups.deleted {
+			fmt.Fprintf(
+				&b, "| %s | %s |\n", markdownCell(action.CategoryTitle), feedCell(action),
+			)
+		}
+	}
+
+	return writeString(w, b.String())
+}
+
+// groupActions splits the actions into created, moved, updated and deleted groups, each sorted by
+// category. A feed which is deleted from one category and created in another is a move.
+func (r *Report) groupActions() markdownGroups {
+	createdFeeds := map[string]Action{}
+	deletedFeeds := map[string]Action{}
+
+	for _, action := range r.Actions {
+		switch action.Type {
+		case diff.CreateFeed:
+			createdFeeds[action.FeedURL] = action
+		case diff.DeleteFeed:
+			deletedFeeds[action.FeedURL] = action
+		}
+	}
+
+	groups := markdownGroups{}
+
+	for _, action := range r.Actions {
+		switch action.Type {
+		case diff.CreateCategory:
+			groups.created = append(groups.created, action)
+
+		case diff.CreateFeed:
+			deleted, isMove := deletedFeeds[action.FeedURL]
+			if !isMove {
+				groups.created = append(groups.created, action)
+				continue
+			}
+
+			groups.moved = append(groups.moved, move{
+				FeedURL:      action.FeedURL,
+				FromCategory: deleted.CategoryTitle,
+				ToCategory:   action.CategoryTitle,
+			})
+
+			// Options changed on a moved feed are still changes, so show them as an update.
+			if len(action.Changes) > 0 {
+				groups.updated = append(groups.updated, action)
+			}
+
+		case diff.UpdateFeed:
+			groups.updated = append(groups.updated, action)
+
+		case diff.DeleteCategory:
+			groups.deleted = append(groups.deleted, action)
+
+		case diff.DeleteFeed:
+			if _, isMove := createdFeeds[action.FeedURL]; !isMove {
+				groups.deleted = append(groups.deleted, action)
+			}
+		}
+	}
+
+	sortActionsByCategory(groups.created)
+	sortActionsByCategory(groups.updated)
+	sortActionsByCategory(groups.deleted)
+	sort.SliceStable(groups.moved, func(i int, j int) bool {
+		return groups.moved[i].FeedURL < groups.moved[j].FeedURL
+	})
+
+	return groups
+}
+
+// sortActionsByCategory sorts actions by category, with category actions before feed actions.
+func sortActionsByCategory(actions []Action) {
+	sort.SliceStable(actions, func(i int, j int) bool {
+		if actions[i].CategoryTitle != actions[j].CategoryTitle {
+			return actions[i].CategoryTitle < actions[j].CategoryTitle
+		}
+
+		return actions[i].FeedURL < actions[j].FeedURL
+	})
+}
+
+func feedCell(action Action) string {
+	if action.FeedURL == "" {
+		return categoryLabel
+	}
+
+	return markdownCell(action.FeedURL)
+}
+
+func changesCell(changes []diff.OptionChange) string {
+	formatted := make([]string, 0, len(changes))
+	for _, change := range changes {
+		formatted = append(formatted, "`"+strings.ReplaceAll(change.String(), "`", "'")+"`")
+	}
+
+	return markdownCell(strings.Join(formatted, "<br>"))
+}
+
+// markdownCell escapes a value so it can be used within a Markdown table cell.
+func markdownCell(s string) string {
+	s = strings.ReplaceAll(s, "|", `\|`)
+	return strings.ReplaceAll(s, "\n", " ")
+}
+
+func writeString(w io.Writer, s string) error {
+	if _, err := io.WriteString(w, s); err != nil {
+		return errors.Wrap(err, "writing markdown report")
+	}
+
+	return nil
+}
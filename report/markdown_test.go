@@ -0,0 +1,137 @@
+package report_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/revett/miniflux-sync/diff"
+	"github.com/revett/miniflux-sync/report"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteMarkdown(t *testing.T) {
+	t.Parallel()
+
+	article := "article"
+	content := "div.content"
+
+	local := &diff.State{
+		FeedURLsByCategoryTitle: map[string][]string{
+			"Music": {"https://music.com/feed", "https://tech.com/feed"},
+		},
+	}
+	remote := &diff.State{
+		FeedURLsByCategoryTitle: map[string][]string{
+			"Tech": {"https://tech.com/feed", "https://old.com/feed"},
+		},
+		FeedsByCategoryTitle: map[string][]diff.Feed{
+			"Tech": {
+				{URL: "https://tech.com/feed", Options: diff.FeedOptions{ScraperRules: &article}},
+				{URL: "https://old.com/feed"},
+			},
+		},
+	}
+	actions := []diff.Action{
+		{Type: diff.DeleteFeed, CategoryTitle: "Tech", FeedURL: "https://old.com/feed"},
+		{Type: diff.DeleteFeed, CategoryTitle: "Tech", FeedURL: "https://tech.com/feed"},
+		{Type: diff.DeleteCategory, CategoryTitle: "Tech"},
+		{Type: diff.CreateCategory, CategoryTitle: "Music"},
+		{Type: diff.CreateFeed, CategoryTitle: "Music", FeedURL: "https://music.com/feed"},
+		{
+			Type:          diff.CreateFeed,
+			CategoryTitle: "Music",
+			FeedURL:       "https://tech.com/feed",
+			FeedOptions:   diff.FeedOptions{ScraperRules: &content},
+		},
+	}
+
+	var b strings.Builder
+	require.NoError(t, report.New(local, remote, actions).WriteMarkdown(&b))
+
+	expected := `## miniflux-sync plan
+
+Local: 2 feeds in 1 categories. Remote: 2 feeds in 1 categories.
+
+**2 to create, 1 to move, 1 to update, 2 to delete.**
+
+### Created
+
+| Category | Feed | Options |
+| --- | --- | --- |
+| Music | _(category)_ |  |
+| Music | https://music.com/feed |  |
+
+### Moved
+
+| Feed | From | To |
+| --- | --- | --- |
+| https://tech.com/feed | Tech | Music |
+
+### Updated
+
+| Category | Feed | Changes |
+| --- | --- | --- |
+| Music | https://tech.com/feed | ` + "`" + `scraper_rules: "article" -> "div.content"` + "`" + ` |
+
+### Deleted
+
+| Category | Feed |
+| --- | --- |
+| Tech | _(category)_ |
+| Tech | https://old.com/feed |
+`
+
+	require.Equal(t, expected, b.String())
+}
+
+func TestWriteMarkdown_MoveWithoutChanges(t *testing.T) {
+	t.Parallel()
+
+	article := "article"
+	options := diff.FeedOptions{ScraperRules: &article}
+
+	local := &diff.State{
+		FeedURLsByCategoryTitle: map[string][]string{
+			"Music": {"https://tech.com/feed"},
+			"Tech":  {},
+		},
+	}
+	remote := &diff.State{
+		FeedURLsByCategoryTitle: map[string][]string{
+			"Music": {},
+			"Tech":  {"https://tech.com/feed"},
+		},
+		FeedsByCategoryTitle: map[string][]diff.Feed{
+			"Tech": {{URL: "https://tech.com/feed", Options: options}},
+		},
+	}
+	actions := []diff.Action{
+		{Type: diff.DeleteFeed, CategoryTitle: "Tech", FeedURL: "https://tech.com/feed"},
+		{
+			Type:          diff.CreateFeed,
+			CategoryTitle: "Music",
+			FeedURL:       "https://tech.com/feed",
+			FeedOptions:   options,
+		},
+	}
+
+	var b strings.Builder
+	require.NoError(t, report.New(local, remote, actions).WriteMarkdown(&b))
+
+	require.Contains(t, b.String(), "**0 to create, 1 to move, 0 to update, 0 to delete.**")
+	require.NotContains(t, b.String(), "### Updated")
+}
+
+func TestWriteMarkdown_NoChanges(t *testing.T) {
+	t.Parallel()
+
+	state := &diff.State{
+		FeedURLsByCategoryTitle: map[string][]string{
+			"Tech": {"https://tech.com/feed"},
+		},
+	}
+
+	var b strings.Builder
+	require.NoError(t, report.New(state, state, []diff.Action{}).WriteMarkdown(&b))
+	require.Contains(t, b.String(), "No changes.")
+}
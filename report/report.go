@@ -0,0 +1,132 @@
+package report
+
+import (
+	"encoding/json"
+	"io"
+
+	"github.com/pkg/errors"
+	"github.com/revett/miniflux-sync/diff"
+)
+
+// Report is a machine-readable description of the actions required to sync the local and remote
+// state.
+type Report struct {
+	Local   Counts   `json:"local"`
+	Remote  Counts   `json:"remote"`
+	Actions []Action `json:"actions"`
+	Summary Summary  `json:"summary"`
+}
+
+// Counts holds the number of feeds and categories in a state.
+type Counts struct {
+	Feeds      int `json:"feeds"`
+	Categories int `json:"categories"`
+}
+
+// Action describes a single action, including any feed options it changes. The changes of a feed
+// moved between categories are relative to the options it had in its previous category.
+type Action struct {
+	Type          diff.ActionType     `json:"type"`
+	CategoryTitle string              `json:"category_title"`
+	FeedURL       string              `json:"feed_url,omitempty"`
+	Changes       []diff.OptionChange `json:"changes,omitempty"`
+}
+
+// Summary holds the number of actions of each type.
+type Summary struct {
+	Total           int  `json:"total"`
+	CreateCategory  int  `json:"create_category"`
+	CreateFeed      int  `json:"create_feed"`
+	UpdateFeed      int  `json:"update_feed"`
+	DeleteCategory  int  `json:"delete_category"`
+	DeleteFeed      int  `json:"delete_feed"`
+	RequiresChanges bool `json:"requires_changes"`
+}
+
+// New builds a report from the local and remote state, and the actions calculated between them.
+func New(local *diff.State, remote *diff.State, actions []diff.Action) *Report {
+	report := Report{
+		Local: Counts{
+			Feeds:      len(local.FeedURLs()),
+			Categories: len(local.CategoryTitles()),
+		},
+		Remote: Counts{
+			Feeds:      len(remote.FeedURLs()),
+			Categories: len(remote.CategoryTitles()),
+		},
+		Actions: make([]Action, 0, len(actions)),
+		Summary: Summary{
+			Total:           len(actions),
+			RequiresChanges: len(actions) > 0,
+		},
+	}
+
+	deletedFeeds := map[string]bool{}
+	for _, action := range actions {
+		if action.Type == diff.DeleteFeed {
+			deletedFeeds[action.FeedURL] = true
+		}
+	}
+
+	for _, action := range actions {
+		entry := Action{
+			Type:          action.Type,
+			CategoryTitle: action.CategoryTitle,
+			FeedURL:       action.FeedURL,
+		}
+
+		switch action.Type {
+		case diff.CreateCategory:
+			report.Summary.CreateCategory++
+
+		case diff.CreateFeed:
+			report.Summary.CreateFeed++
+
+			// A feed which is also deleted is moved between categories, so its options are compared
+			// against those it had before, rather than unset options.
+			if deletedFeeds[action.FeedURL] {
+				entry.Changes = maskSecrets(
+					action.FeedOptions.Changes(remote.GetFeedOptions(action.FeedURL)),
+				)
+				break
+			}
+
+			entry.Changes = maskSecrets(action.OptionChanges())
+
+		case diff.UpdateFeed:
+			report.Summary.UpdateFeed++
+			entry.Changes = maskSecrets(action.OptionChanges())
+
+		case diff.DeleteCategory:
+			report.Summary.DeleteCategory++
+
+		case diff.DeleteFeed:
+			report.Summary.DeleteFeed++
+		}
+
+		report.Actions = append(report.Actions, entry)
+	}
+
+	return &report
+}
+
+// WriteJSON writes the report as a single indented JSON document.
+func (r *Report) WriteJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+
+	if err := encoder.Encode(r); err != nil {
+		return errors.Wrap(err, "encoding report as json")
+	}
+
+	return nil
+}
+
+// maskSecrets replaces the values of any secret options, so they are never displayed.
+func maskSecrets(changes []diff.OptionChange) []diff.OptionChange {
+	for i, change := range changes {
+		changes[i] = change.Masked()
+	}
+
+	return changes
+}
@@ -0,0 +1,105 @@
+package report_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/revett/miniflux-sync/diff"
+	"github.com/revett/miniflux-sync/report"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNew(t *testing.T) {
+	t.Parallel()
+
+	article := "article"
+	content := "div.content"
+	secret := "secret"
+
+	local := &diff.State{
+		FeedURLsByCategoryTitle: map[string][]string{
+			"Tech":  {"https://tech.com/feed"},
+			"Music": {"https://music.com/feed"},
+		},
+	}
+	remote := &diff.State{
+		FeedURLsByCategoryTitle: map[string][]string{
+			"Tech": {"https://tech.com/feed"},
+		},
+		FeedsByCategoryTitle: map[string][]diff.Feed{
+			"Tech": {
+				{URL: "https://tech.com/feed", Options: diff.FeedOptions{ScraperRules: &article}},
+			},
+		},
+	}
+	actions := []diff.Action{
+		{
+			Type:          diff.CreateCategory,
+			CategoryTitle: "Music",
+		},
+		{
+			Type:          diff.CreateFeed,
+			CategoryTitle: "Music",
+			FeedURL:       "https://music.com/feed",
+			FeedOptions:   diff.FeedOptions{Password: &secret},
+		},
+		{
+			Type:              diff.UpdateFeed,
+			CategoryTitle:     "Tech",
+			FeedURL:           "https://tech.com/feed",
+			FeedOptions:       diff.FeedOptions{ScraperRules: &content},
+			RemoteFeedOptions: diff.FeedOptions{ScraperRules: &article},
+		},
+	}
+
+	r := report.New(local, remote, actions)
+
+	require.Equal(t, report.Counts{Feeds: 2, Categories: 2}, r.Local)
+	require.Equal(t, report.Counts{Feeds: 1, Categories: 1}, r.Remote)
+	require.Equal(t, report.Summary{
+		Total:           3,
+		CreateCategory:  1,
+		CreateFeed:      1,
+		UpdateFeed:      1,
+		RequiresChanges: true,
+	}, r.Summary)
+	require.Equal(t, []report.Action{
+		{
+			Type:          diff.CreateCategory,
+			CategoryTitle: "Music",
+		},
+		{
+			Type:          diff.CreateFeed,
+			CategoryTitle: "Music",
+			FeedURL:       "https://music.com/feed",
+			Changes: []diff.OptionChange{
+				{Field: "password", Before: nil, After: "********"},
+			},
+		},
+		{
+			Type:          diff.UpdateFeed,
+			CategoryTitle: "Tech",
+			FeedURL:       "https://tech.com/feed",
+			Changes: []diff.OptionChange{
+				{Field: "scraper_rules", Before: "article", After: "div.content"},
+			},
+		},
+	}, r.Actions)
+}
+
+func TestWriteJSON(t *testing.T) {
+	t.Parallel()
+
+	state := &diff.State{
+		FeedURLsByCategoryTitle: map[string][]string{},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, report.New(state, state, []diff.Action{}).WriteJSON(&buf))
+
+	var decoded map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &decoded))
+	require.Equal(t, []any{}, decoded["actions"])
+	require.Contains(t, decoded, "summary")
+}